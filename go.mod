@@ -1,5 +1,7 @@
 module github.com/wojteninho/scanner
 
+go 1.23
+
 require (
 	github.com/fsnotify/fsnotify v1.4.7 // indirect
 	github.com/go-test/deep v1.0.1
@@ -9,7 +11,7 @@ require (
 	github.com/onsi/gomega v1.4.1
 	golang.org/x/net v0.0.0-20180801234040-f4c29de78a2a // indirect
 	golang.org/x/sync v0.0.0-20181108010431-42b317875d0f // indirect
-	golang.org/x/sys v0.0.0-20181213081344-73d4af5aa059 // indirect
+	golang.org/x/sys v0.30.0
 	golang.org/x/text v0.3.0 // indirect
 	gopkg.in/fsnotify.v1 v1.4.7 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
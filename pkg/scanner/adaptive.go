@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+var ErrInvalidWorkersRange = errors.New("invalid range of workers")
+
+const (
+	// adaptiveWindow is the shortest period the throughput is measured over
+	adaptiveWindow = 20 * time.Millisecond
+	// adaptiveTolerance is the relative change of the throughput treated as a noise
+	adaptiveTolerance = 0.1
+	// adaptiveLatencyGrowth is the growth of the read latency which means the storage is saturated
+	adaptiveLatencyGrowth = 1.5
+)
+
+// WithAdaptiveWorkers keeps between min and max workers, as many as the storage serves best. The
+// number is tuned while scanning, see RecursiveScanner.Workers. It applies to the unordered
+// StrategyParallel only, the other scans run max workers.
+func WithAdaptiveWorkers(minWorkers, maxWorkers uint) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		if minWorkers == 0 || maxWorkers < minWorkers {
+			return ErrInvalidWorkersRange
+		}
+
+		s.workers = maxWorkers
+		s.minWorkers, s.adaptive = minWorkers, true
+		return nil
+	}
+}
+
+// Workers is the number of the workers the most recently started scan runs at most, the last one
+// once it is done. The scans running concurrently keep their own numbers, the older ones never
+// overwrite the one of the newer scan. It changes only with WithAdaptiveWorkers.
+func (s *RecursiveScanner) Workers() uint {
+	if current := s.currentWorkers.Load(); current > 0 {
+		return uint(uint32(current))
+	}
+
+	return s.initialWorkers()
+}
+
+// startScan numbers the scan, the number of its workers is stored under it, see setWorkers.
+func (s *RecursiveScanner) startScan() uint32 {
+	return s.scans.Add(1)
+}
+
+// setWorkers stores the number of the workers of the scan, unless a newer scan has started since.
+func (s *RecursiveScanner) setWorkers(scan uint32, workers uint) {
+	for {
+		current := s.currentWorkers.Load()
+		if uint32(current>>32) > scan {
+			return
+		}
+
+		if s.currentWorkers.CompareAndSwap(current, uint64(scan)<<32|uint64(workers)) {
+			return
+		}
+	}
+}
+
+func (s *RecursiveScanner) initialWorkers() uint {
+	if !s.adaptive {
+		return s.workers
+	}
+
+	return min(max(uint(runtime.NumCPU()), s.minWorkers), s.workers)
+}
+
+// directoryStats is measured by the worker while reading the directory.
+type directoryStats struct {
+	items   int
+	latency time.Duration
+}
+
+// workersController tunes the number of the workers by hill climbing. It keeps moving the number in
+// the same direction while the throughput grows and turns back once it drops. When the throughput
+// stays the same but reading gets slower, the storage is saturated and the number goes down.
+type workersController struct {
+	minWorkers uint
+	maxWorkers uint
+	workers    uint
+	step       int
+
+	windowStart time.Time
+	items       int
+	dirs        int
+	latency     time.Duration
+
+	// throughput & latency of the previous window
+	lastThroughput float64
+	lastLatency    time.Duration
+}
+
+func newWorkersController(minWorkers, maxWorkers, workers uint) *workersController {
+	return &workersController{
+		minWorkers:  minWorkers,
+		maxWorkers:  maxWorkers,
+		workers:     workers,
+		step:        1,
+		windowStart: time.Now(),
+	}
+}
+
+// observe records the directory and returns the number of the workers to run from now on.
+func (c *workersController) observe(stats directoryStats) uint {
+	c.items += stats.items
+	c.dirs++
+	c.latency += stats.latency
+
+	elapsed := time.Since(c.windowStart)
+	if elapsed < adaptiveWindow || uint(c.dirs) < c.workers {
+		return c.workers
+	}
+
+	throughput := float64(c.items) / elapsed.Seconds()
+	latency := c.latency / time.Duration(c.dirs)
+
+	switch {
+	case c.lastThroughput == 0:
+		// the very first window, nothing to compare with
+	case throughput < c.lastThroughput*(1-adaptiveTolerance):
+		c.step = -c.step
+	case throughput <= c.lastThroughput*(1+adaptiveTolerance) &&
+		float64(latency) > float64(c.lastLatency)*adaptiveLatencyGrowth:
+		c.step = -1
+	}
+
+	c.lastThroughput, c.lastLatency = throughput, latency
+	c.windowStart, c.items, c.dirs, c.latency = time.Now(), 0, 0, 0
+
+	// the bigger the pool, the bigger the step, so it gets to the hundreds of workers quickly
+	delta := max(c.workers/4, 1)
+	if c.step > 0 {
+		c.workers = min(c.workers+delta, c.maxWorkers)
+	} else {
+		c.workers = max(c.workers-min(delta, c.workers), c.minWorkers)
+	}
+
+	// at the bound, the only way to go is back
+	if c.workers == c.maxWorkers {
+		c.step = -1
+	} else if c.workers == c.minWorkers {
+		c.step = 1
+	}
+
+	return c.workers
+}
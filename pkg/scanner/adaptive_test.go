@@ -0,0 +1,121 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// SlowFS reads every directory with the delay, the way the network filesystems do.
+type SlowFS struct {
+	fstest.MapFS
+	delay time.Duration
+}
+
+func (fsys SlowFS) Open(name string) (fs.File, error) {
+	f, err := fsys.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := f.(fs.ReadDirFile); ok {
+		return slowDir{d, fsys.delay}, nil
+	}
+
+	return f, nil
+}
+
+type slowDir struct {
+	fs.ReadDirFile
+	delay time.Duration
+}
+
+func (d slowDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	time.Sleep(d.delay)
+	return d.ReadDirFile.ReadDir(n)
+}
+
+func TestWithAdaptiveWorkers(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 300; i++ {
+		for j := 0; j < 3; j++ {
+			fsys[fmt.Sprintf("level-0-directory-%d/level-1-file-%d.%d", i, i, j)] = &fstest.MapFile{}
+		}
+	}
+
+	t.Run("When range of workers is invalid", ScannerTest(func(t *testing.T) {
+		_, err := NewRecursiveScanner(WithAdaptiveWorkers(0, 4))
+		Expect(err).To(Equal(ErrInvalidWorkersRange))
+
+		_, err = NewRecursiveScanner(WithAdaptiveWorkers(4, 2))
+		Expect(err).To(Equal(ErrInvalidWorkersRange))
+	}))
+
+	t.Run("When scan has not started yet", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithWorkers(3))).(*RecursiveScanner)
+		Expect(s.Workers()).To(Equal(uint(3)))
+
+		s = MustScanner(NewRecursiveScanner(WithAdaptiveWorkers(2, 1000))).(*RecursiveScanner)
+		Expect(s.Workers()).To(Equal(max(uint(runtime.NumCPU()), 2)))
+
+		s = MustScanner(NewRecursiveScanner(WithAdaptiveWorkers(1, 1))).(*RecursiveScanner)
+		Expect(s.Workers()).To(Equal(uint(1)))
+
+		s = MustScanner(NewRecursiveScanner(WithAdaptiveWorkers(2, 1000), WithWorkers(3))).(*RecursiveScanner)
+		Expect(s.Workers()).To(Equal(uint(3)))
+	}))
+
+	t.Run("When storage is slow", ScannerTest(func(t *testing.T) {
+		var (
+			initial  = uint(runtime.NumCPU())
+			maxLimit = 4 * initial
+			s        = MustScanner(NewRecursiveScanner(
+				WithDirectories("."),
+				WithAdaptiveWorkers(1, maxLimit),
+				WithScannerOptions(WithFS(SlowFS{fsys, time.Millisecond})),
+			)).(*RecursiveScanner)
+			observed []uint
+		)
+
+		expected := FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(fsys)))).Scan(context.TODO())))
+
+		var pathNames []string
+		for item := range MustScan(s.Scan(context.TODO())) {
+			pathNames = append(pathNames, item.String())
+			observed = append(observed, s.Workers())
+		}
+
+		Expect(SortedPathNames(pathNames)).To(Equal(SortedPathNames(expected)))
+		Expect(observed).To(ContainElement(BeNumerically(">", initial)))
+		for _, workers := range observed {
+			Expect(workers).To(And(BeNumerically(">=", 1), BeNumerically("<=", maxLimit)))
+		}
+	}))
+
+	t.Run("When scans run concurrently", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(
+			WithDirectories("."),
+			WithAdaptiveWorkers(1, 4*uint(runtime.NumCPU())),
+			WithScannerOptions(WithFS(SlowFS{fsys, time.Millisecond})),
+		)).(*RecursiveScanner)
+
+		older := MustScan(s.Scan(context.TODO()))
+		<-older
+
+		// the older scan goes on once the newer one is done, it must not overwrite its number
+		FileChanToSlice(MustScan(s.Scan(context.TODO())))
+		newer := s.Workers()
+
+		for range older {
+			Expect(s.Workers()).To(Equal(newer))
+		}
+		Expect(s.Workers()).To(Equal(newer))
+	}))
+}
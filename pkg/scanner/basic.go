@@ -3,30 +3,120 @@ package scanner
 import (
 	"context"
 	"io"
+	"io/fs"
+	"iter"
 	"os"
+	"path"
 )
 
 type BasicScannerOptionFn func(s *BasicScanner) error
 
+// WithDir sets the directory to scan, it has to exist in the filesystem the scanner reads.
 func WithDir(directory string) BasicScannerOptionFn {
 	return func(s *BasicScanner) error {
-		info, err := os.Stat(directory)
-		if err != nil {
-			return err
+		s.directory = directory
+		return nil
+	}
+}
+
+// WithFS reads the directories through fsys instead of the OS. Paths are the fs.FS ones then,
+// slash separated and unrooted, e.g. "." or "photos/2019".
+func WithFS(fsys fs.FS) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.fsys = ioFileSystem{fsys}
+		return nil
+	}
+}
+
+func WithBulkSize(bulkSize int) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.bulkSize = bulkSize
+		return nil
+	}
+}
+
+func WithOrder(order Order) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.order = order
+		return nil
+	}
+}
+
+// WithLazyStat reads the directory entries without the stat of every single one. The file is
+// stat-ed the first time anything but its name or type is needed, e.g. Size or ModTime.
+func WithLazyStat() BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.lazyStat = true
+		return nil
+	}
+}
+
+// WithGetdents reads the OS directories with the getdents64 syscall into a reusable buffer, the
+// same lazy way WithLazyStat does. It's Linux only, elsewhere and with WithFS it is WithLazyStat.
+func WithGetdents() BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.lazyStat, s.getdents = true, true
+		return nil
+	}
+}
+
+// WithOpenat opens every directory relative to its parent with O_NOFOLLOW|O_DIRECTORY, so the scan
+// can't be redirected by a directory swapped for a symlink in the meantime, nor fails on the paths
+// longer than PATH_MAX. Items carry the opened directory, see FileItem.Open. It's Linux only and it
+// reads the OS directly, so it can't go with WithFS.
+func WithOpenat() BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		if !openatSupported {
+			return ErrOpenatNotSupported
 		}
 
-		if !info.IsDir() {
-			return ErrNotDirectory
+		s.openat = true
+		return nil
+	}
+}
+
+// WithStatx stats the files with statx, their FileInfo is StatxFileInfo then, see StatxOf. It's
+// Linux only and it reads the OS directly, so it can't go with WithFS.
+func WithStatx() BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		if !statxSupported {
+			return ErrStatxNotSupported
 		}
 
-		s.directory = directory
+		s.statx = true
 		return nil
 	}
 }
 
-func WithBulkSize(bulkSize int) BasicScannerOptionFn {
+func WithDirEvents() BasicScannerOptionFn {
 	return func(s *BasicScanner) error {
-		s.bulkSize = bulkSize
+		s.dirEvents = true
+		return nil
+	}
+}
+
+// withRoot places the scanned directory at relDir inside of the root, the items found in the
+// directory get the depth then. RecursiveScanner uses it to scan every single directory.
+func withRoot(root, relDir string, depth uint) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.root, s.relDir, s.depth = root, relDir, depth
+		return nil
+	}
+}
+
+// withParent opens the scanned directory relative to the parent one, in the openat mode.
+func withParent(parent *Dir) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.parent = parent
+		return nil
+	}
+}
+
+// withDirCache opens the scanned root directory into the cache of the recursive scan, in the
+// openat mode. The directories found in it share the cache of their parent.
+func withDirCache(dirs *dirCache) BasicScannerOptionFn {
+	return func(s *BasicScanner) error {
+		s.dirs = dirs
 		return nil
 	}
 }
@@ -34,11 +124,28 @@ func WithBulkSize(bulkSize int) BasicScannerOptionFn {
 type BasicScanner struct {
 	directory string
 	bulkSize  int
+	order     Order
+	dirEvents bool
+	lazyStat  bool
+	getdents  bool
+	openat    bool
+	statx     bool
+	fsys      fileSystem
+
+	// parent is the opened parent directory, set for the directories found in the openat mode
+	parent *Dir
+	dirs   *dirCache
+
+	// root, relDir & depth default to the directory itself
+	root   string
+	relDir string
+	depth  uint
 }
 
 func NewBasicScanner(options ...BasicScannerOptionFn) (*BasicScanner, error) {
 	s := BasicScanner{
 		bulkSize: 20,
+		fsys:     osFileSystem{},
 	}
 
 	for _, option := range options {
@@ -47,6 +154,30 @@ func NewBasicScanner(options ...BasicScannerOptionFn) (*BasicScanner, error) {
 		}
 	}
 
+	if s.openat && s.fsys != (osFileSystem{}) {
+		return nil, ErrOpenatNotSupported
+	}
+
+	if s.statx && s.fsys != (osFileSystem{}) {
+		return nil, ErrStatxNotSupported
+	}
+
+	// directory found in the openat mode is checked once opened, its path may be too long to stat
+	if s.directory != "" && s.parent == nil {
+		info, err := s.fsys.Stat(s.directory)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			return nil, ErrNotDirectory
+		}
+	}
+
+	if s.root == "" {
+		s.root, s.relDir, s.depth = s.directory, ".", 1
+	}
+
 	return &s, nil
 }
 
@@ -57,31 +188,300 @@ func (s *BasicScanner) Scan(ctx context.Context) (FileItemChan, error) {
 		return fileChan, nil
 	}
 
-	d, err := os.Open(s.directory)
+	d, dir, err := s.openDir()
 	if err != nil {
 		return nil, err
 	}
 
 	go func() {
-		defer d.Close()
 		defer close(fileChan)
+		defer dir.release()
 
-		for {
-			bulk, err := d.Readdir(s.bulkSize)
+		s.read(ctx, d, dir, func(item FileItem) bool {
+			return send(ctx, fileChan, item)
+		})
+	}()
 
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
+	return fileChan, nil
+}
+
+// ScanBatches sends a batch once it is full, regardless of the bulks Readdir is called with.
+func (s *BasicScanner) ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error) {
+	batchChan := make(FileItemBatchChan)
+	if s.directory == "" {
+		defer close(batchChan)
+		return batchChan, nil
+	}
+
+	d, dir, err := s.openDir()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(batchChan)
+		defer dir.release()
+
+		b := newBatcher(ctx, batchChan, size)
+		s.read(ctx, d, dir, b.yield)
+		b.flush()
+	}()
+
+	return batchChan, nil
+}
+
+// All iterates over the directory in the calling goroutine.
+func (s *BasicScanner) All(ctx context.Context) iter.Seq2[FileInfo, error] {
+	return entries(s.items(ctx))
+}
 
-				fileChan <- FileItem{nil, err}
+func (s *BasicScanner) items(ctx context.Context) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		if s.directory == "" {
+			return
+		}
+
+		d, dir, err := s.openDir()
+		if err != nil {
+			yield(FileItem{Err: err})
+			return
+		}
+
+		defer dir.release()
+		s.read(ctx, d, dir, yield)
+	}
+}
+
+func (s *BasicScanner) walk(ctx context.Context, emit emitFn) {
+	var skipRest bool
+
+	for item := range s.items(ctx) {
+		if skipRest && item.Kind == KindEntry {
+			continue
+		}
+
+		switch emit(item) {
+		case walkStop:
+			return
+		case walkSkipDir:
+			// there is nothing to descend into, but anything else skips the rest of the listing
+			if item.Kind == KindDirStart || (item.Kind == KindEntry && (item.FileInfo == nil || !item.FileInfo.IsDir())) {
+				skipRest = true
 			}
+		}
+	}
+}
+
+// traversal is deterministic only when the directory is sorted.
+func (s *BasicScanner) traversal() (traversal, bool) {
+	return traversal{Kind: traversalDirectory, Order: s.order}, s.order != OrderNone
+}
+
+func (s *BasicScanner) seek(ctx context.Context, pos position) iter.Seq[FileItem] {
+	t, _ := s.traversal()
+	return t.seek(ctx, s, pos)
+}
+
+// openDir opens the directory to read, the Dir is returned in the openat mode only. It's pinned by
+// the listing until read is done & referenced until released, see Dir.
+func (s *BasicScanner) openDir() (dirFile, *Dir, error) {
+	if !s.openat {
+		d, err := s.fsys.OpenDir(s.directory)
+		return d, nil, err
+	}
+
+	var (
+		dir *Dir
+		err error
+	)
+
+	if s.parent != nil {
+		dir, err = s.parent.openDir(path.Base(s.directory))
+	} else {
+		dir, err = openRootDir(s.directory, s.dirs)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dir.file, dir, nil
+}
+
+// read passes the items of the opened directory to yield, until it returns false. Dir is unpinned
+// afterwards, but it's left to the caller to release it.
+func (s *BasicScanner) read(ctx context.Context, d dirFile, dir *Dir, yield func(FileItem) bool) {
+	if dir == nil {
+		defer d.Close()
+	} else {
+		defer dir.unpin()
+	}
+
+	if !s.dirEvents {
+		s.readEntries(ctx, d, dir, yield)
+		return
+	}
+
+	dirStart, ok := s.dirStartItem(d)
+	if !ok {
+		s.readEntries(ctx, d, dir, yield)
+		return
+	}
+
+	if !yield(dirStart) || !s.readEntries(ctx, d, dir, yield) {
+		return
+	}
+
+	dirEnd := dirStart
+	dirEnd.Kind = KindDirEnd
+	yield(dirEnd)
+}
 
+func (s *BasicScanner) readEntries(ctx context.Context, d dirFile, dir *Dir, yield func(FileItem) bool) bool {
+	// sorting needs the whole directory to be read upfront
+	var sorted []os.FileInfo
+
+	r := s.bulkReader(d, dir)
+	defer r.release()
+
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		bulk, err := r.readBulk()
+
+		if s.order != OrderNone {
+			sorted = append(sorted, bulk...)
+		} else {
 			for _, info := range bulk {
-				fileChan <- FileItem{NewFile(info, s.directory), nil}
+				if !yield(FileItem{FileInfo: s.newFile(info), Dir: dir}) {
+					return false
+				}
 			}
 		}
-	}()
 
-	return fileChan, nil
+		if err != nil {
+			// directory can't be read any further, retrying would fail forever
+			if err != io.EOF && !yield(FileItem{Err: err}) {
+				return false
+			}
+
+			break
+		}
+	}
+
+	s.order.sortFileInfos(sorted)
+	for _, info := range sorted {
+		if !yield(FileItem{FileInfo: s.newFile(info), Dir: dir}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bulkReader reads the opened directory bulk by bulk, until io.EOF.
+type bulkReader interface {
+	readBulk() ([]os.FileInfo, error)
+	release()
+}
+
+func (s *BasicScanner) bulkReader(d dirFile, dir *Dir) bulkReader {
+	lstat := s.lstatFn(dir)
+
+	if s.getdents {
+		if lstat == nil {
+			lstat = func(name string) (os.FileInfo, error) {
+				return os.Lstat(path.Join(s.directory, name))
+			}
+		}
+
+		if r, ok := newGetdentsReader(d, lstat); ok {
+			return r
+		}
+	}
+
+	return dirFileReader{d, lstat, s.bulkSize, s.lazyStat}
+}
+
+// lstatFn stats the entries of the directory, it is nil when the entries can stat themselves.
+func (s *BasicScanner) lstatFn(dir *Dir) func(name string) (os.FileInfo, error) {
+	switch {
+	case s.statx && dir != nil:
+		return func(name string) (os.FileInfo, error) {
+			var info os.FileInfo
+			err := dir.use(func(f *os.File) (err error) {
+				info, err = statx(f, name)
+				return err
+			})
+
+			return info, err
+		}
+	case s.statx:
+		return func(name string) (os.FileInfo, error) {
+			return statx(nil, path.Join(s.directory, name))
+		}
+	case dir != nil:
+		// the entry would be stat-ed by its path otherwise
+		return dir.Lstat
+	}
+
+	return nil
+}
+
+type dirFileReader struct {
+	d        dirFile
+	lstat    func(name string) (os.FileInfo, error)
+	bulkSize int
+	lazyStat bool
+}
+
+func (r dirFileReader) readBulk() ([]os.FileInfo, error) {
+	if !r.lazyStat && r.lstat == nil {
+		return r.d.Readdir(r.bulkSize)
+	}
+
+	entries, err := r.d.ReadDir(r.bulkSize)
+	if r.lstat != nil {
+		for i, entry := range entries {
+			entries[i] = statDirEntry{entry, r.lstat}
+		}
+	}
+
+	if !r.lazyStat {
+		infos, infoErr := entryInfos(entries)
+		if infoErr != nil {
+			return infos, infoErr
+		}
+
+		return infos, err
+	}
+
+	infos := make([]os.FileInfo, len(entries))
+	for i, entry := range entries {
+		infos[i] = newLazyFileInfo(entry)
+	}
+
+	return infos, err
+}
+
+func (r dirFileReader) release() {}
+
+func (s *BasicScanner) dirStartItem(d dirFile) (FileItem, bool) {
+	info, err := d.Stat()
+	if f, ok := d.(*os.File); ok && s.statx {
+		info, err = statxFile(f)
+	}
+
+	if err != nil {
+		return FileItem{}, false
+	}
+
+	dir := File{info, path.Dir(path.Clean(s.directory)), s.root, s.relDir, s.depth - 1}
+	return FileItem{FileInfo: dir, Kind: KindDirStart, Dir: s.parent}, true
+}
+
+func (s *BasicScanner) newFile(info os.FileInfo) File {
+	return File{info, s.directory, s.root, path.Join(s.relDir, info.Name()), s.depth}
 }
@@ -158,3 +158,77 @@ func TestBasicScanner(t *testing.T) {
 		)))
 	}))
 }
+
+func TestBasicScannerMetadata(t *testing.T) {
+	t.Run("When directory is not empty", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("flat-directory-with-metadata")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("level-0-directory-1"),
+			NewWorkspaceFile("level-0-file-1.jpg"),
+		)).Purge()
+
+		fileChan, err := MustScanner(NewBasicScanner(WithDir(dir), WithDirEvents(), WithOrder(OrderLexical))).Scan(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+
+		files := FileChanToSlice(fileChan)
+		Expect(files).To(HaveLen(4))
+		for _, file := range files {
+			Expect(file.FileInfo.Root()).To(Equal(dir))
+		}
+
+		Expect(files[0].FileInfo.RelPath()).To(Equal("."))
+		Expect(files[0].FileInfo.Depth()).To(BeZero())
+		Expect(files[1].FileInfo.RelPath()).To(Equal("level-0-directory-1"))
+		Expect(files[1].FileInfo.Depth()).To(Equal(uint(1)))
+		Expect(files[2].FileInfo.RelPath()).To(Equal("level-0-file-1.jpg"))
+		Expect(files[2].FileInfo.Depth()).To(Equal(uint(1)))
+	}))
+}
+
+func TestBasicScannerCancel(t *testing.T) {
+	dir := NewDirectoryPath("directory-to-cancel")
+	defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", 50)...)).Purge()
+
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		ExpectCancelToStopScan(MustScanner(NewBasicScanner(WithDir(dir), WithBulkSize(5))))
+	}))
+
+	t.Run("When context is cancelled and directory events are emitted", ScannerTest(func(t *testing.T) {
+		ExpectCancelToStopScan(MustScanner(NewBasicScanner(WithDir(dir), WithDirEvents())))
+	}))
+
+	t.Run("When context is cancelled and order is set", ScannerTest(func(t *testing.T) {
+		ExpectCancelToStopScan(MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical))))
+	}))
+}
+
+func TestBasicScannerDirEvents(t *testing.T) {
+	t.Run("When directory is not empty", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("flat-directory-with-dir-events")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("level-0-directory-1"),
+			NewWorkspaceFile("level-0-file-1.jpg"),
+		)).Purge()
+
+		fileChan, err := MustScanner(NewBasicScanner(WithDir(dir), WithDirEvents())).Scan(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+
+		files := FileChanToSlice(fileChan)
+		Expect(files).To(HaveLen(4))
+		Expect(files[0].Kind).To(Equal(KindDirStart))
+		Expect(files[0].FileInfo.PathName()).To(Equal(dir))
+		Expect(files[1].Kind).To(Equal(KindEntry))
+		Expect(files[2].Kind).To(Equal(KindEntry))
+		Expect(files[3].Kind).To(Equal(KindDirEnd))
+		Expect(files[3].FileInfo.PathName()).To(Equal(dir))
+	}))
+
+	t.Run("When directory is empty", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("empty-directory-with-dir-events")
+		defer MustNewWorkspace(dir).Purge()
+
+		fileChan, err := MustScanner(NewBasicScanner(WithDir(dir+"/"), WithDirEvents())).Scan(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToPathNames, Equal([]string{dir, dir})))
+	}))
+}
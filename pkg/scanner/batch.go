@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchSize is used when the batch size is not positive.
+const DefaultBatchSize = 256
+
+// FileItemBatchChan delivers the items in chunks, every one of them owned by the receiver.
+type FileItemBatchChan chan []FileItem
+
+// BatchScanner is implemented by the scanners able to deliver the items in batches end to end,
+// with no channel send per item on the way.
+type BatchScanner interface {
+	ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error)
+}
+
+// ScanBatches delivers the items of the scanner in batches of up to size items. Scanners which
+// can't do it natively are batched from their channel.
+func ScanBatches(ctx context.Context, s Scanner, size int) (FileItemBatchChan, error) {
+	if bs, ok := s.(BatchScanner); ok {
+		return bs.ScanBatches(ctx, size)
+	}
+
+	fileChan, err := s.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batchChan := make(FileItemBatchChan)
+
+	go func() {
+		defer close(batchChan)
+
+		b := newBatcher(ctx, batchChan, size)
+		for item := range fileChan {
+			if b.emit(item) == walkStop {
+				return
+			}
+		}
+
+		b.flush()
+	}()
+
+	return batchChan, nil
+}
+
+func MustBatchScan(batchChan FileItemBatchChan, err error) FileItemBatchChan {
+	if err != nil {
+		panic(err)
+	}
+
+	return batchChan
+}
+
+// Unbatch adapts the batches to the channel of single items. The context has to be the one the
+// batches are scanned with, so cancelling it stops both.
+func Unbatch(ctx context.Context, batchChan FileItemBatchChan) FileItemChan {
+	fileChan := make(FileItemChan)
+
+	go func() {
+		defer close(fileChan)
+
+		for batch := range batchChan {
+			for _, item := range batch {
+				if !send(ctx, fileChan, item) {
+					return
+				}
+			}
+		}
+	}()
+
+	return fileChan
+}
+
+func sendBatch(ctx context.Context, batchChan FileItemBatchChan, batch []FileItem) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case batchChan <- batch:
+		return true
+	}
+}
+
+// batcher collects the emitted items and sends them once the batch is full. Workers of the
+// RecursiveScanner emit concurrently, the batch is sent outside of the lock though.
+type batcher struct {
+	ctx       context.Context
+	batchChan FileItemBatchChan
+	size      int
+
+	mu    sync.Mutex
+	batch []FileItem
+}
+
+func newBatcher(ctx context.Context, batchChan FileItemBatchChan, size int) *batcher {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	return &batcher{ctx: ctx, batchChan: batchChan, size: size}
+}
+
+func (b *batcher) emit(item FileItem) walkAction {
+	b.mu.Lock()
+	if b.batch == nil {
+		b.batch = make([]FileItem, 0, b.size)
+	}
+
+	b.batch = append(b.batch, item)
+
+	var full []FileItem
+	if len(b.batch) == b.size {
+		full, b.batch = b.batch, nil
+	}
+	b.mu.Unlock()
+
+	if full != nil && !sendBatch(b.ctx, b.batchChan, full) {
+		return walkStop
+	}
+
+	return walkContinue
+}
+
+func (b *batcher) yield(item FileItem) bool {
+	return b.emit(item) != walkStop
+}
+
+// flush sends the last, incomplete batch.
+func (b *batcher) flush() {
+	b.mu.Lock()
+	batch := b.batch
+	b.batch = nil
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		sendBatch(b.ctx, b.batchChan, batch)
+	}
+}
@@ -0,0 +1,64 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+type MakeScannerFn func(directory string) Scanner
+
+func BenchmarkScanBatches(b *testing.B) {
+	var scanners = []struct {
+		Name          string
+		MakeScannerFn MakeScannerFn
+	}{
+		{Name: "BasicScanner", MakeScannerFn: func(directory string) Scanner {
+			return MustScanner(NewBasicScanner(WithDir(directory), WithBulkSize(1000)))
+		}},
+		{Name: "RecursiveScanner", MakeScannerFn: func(directory string) Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(directory)))
+		}},
+		{Name: "FilterScanner+DebugScanner+RecursiveScanner", MakeScannerFn: func(directory string) Scanner {
+			return NewFilterRegularFilesScanner(NewDebugScanner(MustScanner(NewRecursiveScanner(WithDirectories(directory))), func(FileItem) {}))
+		}},
+	}
+
+	for _, s := range scanners {
+		for _, filesNumber := range []uint{1000, 10000, 100000} {
+			b.Run(fmt.Sprintf("%s/filesNumber-%d/Scan", s.Name, filesNumber), func(b *testing.B) {
+				b.StopTimer()
+				dir := NewDirectoryPath(fmt.Sprintf("directory-with-%d-files", filesNumber))
+				defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", filesNumber)...)).Purge()
+				scanner := s.MakeScannerFn(dir)
+				b.StartTimer()
+
+				for i := 0; i < b.N; i++ {
+					for f := range MustScan(scanner.Scan(context.TODO())) {
+						f.FileInfo.Name()
+					}
+				}
+			})
+
+			for _, batchSize := range []int{16, 256, 4096} {
+				b.Run(fmt.Sprintf("%s/filesNumber-%d/ScanBatches-%d", s.Name, filesNumber, batchSize), func(b *testing.B) {
+					b.StopTimer()
+					dir := NewDirectoryPath(fmt.Sprintf("directory-with-%d-files", filesNumber))
+					defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", filesNumber)...)).Purge()
+					scanner := s.MakeScannerFn(dir)
+					b.StartTimer()
+
+					for i := 0; i < b.N; i++ {
+						for batch := range MustBatchScan(ScanBatches(context.TODO(), scanner, batchSize)) {
+							for _, f := range batch {
+								f.FileInfo.Name()
+							}
+						}
+					}
+				})
+			}
+		}
+	}
+}
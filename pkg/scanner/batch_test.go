@@ -0,0 +1,176 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// UnbatchedScanner scans the batches and passes them through Unbatch.
+type UnbatchedScanner struct {
+	scanner Scanner
+	size    int
+}
+
+func (s UnbatchedScanner) Scan(ctx context.Context) (FileItemChan, error) {
+	batchChan, err := ScanBatches(ctx, s.scanner, s.size)
+	if err != nil {
+		return nil, err
+	}
+
+	return Unbatch(ctx, batchChan), nil
+}
+
+// BatchChanToPathNames flattens the batches and returns the sizes of them along the way.
+func BatchChanToPathNames(batchChan FileItemBatchChan) ([]string, []int) {
+	var (
+		pathNames []string
+		sizes     []int
+	)
+
+	for batch := range batchChan {
+		sizes = append(sizes, len(batch))
+		for _, item := range batch {
+			pathNames = append(pathNames, item.String())
+		}
+	}
+
+	return pathNames, sizes
+}
+
+func TestScanBatches(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-batch")
+	defer MustNewWorkspace(dir, WithItems(
+		append(NewWorkspaceFiles("level-0-file", 10),
+			NewWorkspaceDir("level-0-directory-1", NewWorkspaceFiles("level-1-file-1", 7)...),
+			NewWorkspaceDir("level-0-directory-2",
+				NewWorkspaceDir("level-1-directory-2.1", NewWorkspaceFiles("level-2-file-2.1", 5)...),
+			),
+		)...,
+	)).Purge()
+
+	scanners := map[string]func() Scanner{
+		"BasicScanner": func() Scanner {
+			return MustScanner(NewBasicScanner(WithDir(dir), WithBulkSize(3)))
+		},
+		"parallel RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4), WithScannerOptions(WithDirEvents())))
+		},
+		"depth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyDepthFirst)))
+		},
+		"FilterScanner": func() Scanner {
+			return NewFilterRegularFilesScanner(MustScanner(NewRecursiveScanner(WithDirectories(dir))))
+		},
+		"DebugScanner": func() Scanner {
+			return NewDebugScanner(MustScanner(NewRecursiveScanner(WithDirectories(dir))), func(FileItem) {})
+		},
+		"MultiScanner": func() Scanner {
+			return NewMultiScanner(
+				MustScanner(NewBasicScanner(WithDir(dir))),
+				MustScanner(NewRecursiveScanner(WithDirectories(dir+"/level-0-directory-2"))),
+			)
+		},
+		"ordered MultiScanner": func() Scanner {
+			return NewOrderedMultiScanner(OrderLexical,
+				MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical))),
+				MustScanner(NewRecursiveScanner(WithDirectories(dir+"/level-0-directory-1"), WithScannerOptions(WithOrder(OrderLexical)))),
+			)
+		},
+		"not batching scanner": func() Scanner {
+			return ChannelScanner{MustScanner(NewRecursiveScanner(WithDirectories(dir)))}
+		},
+	}
+
+	for name, newScanner := range scanners {
+		t.Run(fmt.Sprintf("When batching %s", name), ScannerTest(func(t *testing.T) {
+			expected := FileChanToPathNames(MustScan(newScanner().Scan(context.TODO())))
+
+			for _, size := range []int{1, 4, 1000} {
+				pathNames, sizes := BatchChanToPathNames(MustBatchScan(ScanBatches(context.TODO(), newScanner(), size)))
+				Expect(SortedPathNames(pathNames)).To(Equal(SortedPathNames(append([]string(nil), expected...))))
+				for _, batchSize := range sizes {
+					Expect(batchSize).To(And(BeNumerically(">", 0), BeNumerically("<=", size)))
+				}
+
+				unbatched := FileChanToPathNames(MustScan(UnbatchedScanner{newScanner(), size}.Scan(context.TODO())))
+				Expect(SortedPathNames(unbatched)).To(Equal(SortedPathNames(append([]string(nil), expected...))))
+			}
+		}))
+	}
+
+	t.Run("When batching sorted scanners", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyBreadthFirst), WithScannerOptions(WithOrder(OrderNatural))))
+		expected := FileChanToPathNames(MustScan(s.Scan(context.TODO())))
+
+		pathNames, sizes := BatchChanToPathNames(MustBatchScan(ScanBatches(context.TODO(), s, 4)))
+		Expect(pathNames).To(Equal(expected))
+		Expect(sizes).To(Equal([]int{4, 4, 4, 4, 4, 4, 1}))
+	}))
+
+	t.Run("When batch size is not positive", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewBasicScanner(WithDir(dir)))
+
+		_, sizes := BatchChanToPathNames(MustBatchScan(ScanBatches(context.TODO(), s, 0)))
+		Expect(sizes).To(Equal([]int{12}))
+	}))
+
+	t.Run("When directory is not set", ScannerTest(func(t *testing.T) {
+		pathNames, _ := BatchChanToPathNames(MustBatchScan(ScanBatches(context.TODO(), MustScanner(NewBasicScanner()), 4)))
+		Expect(pathNames).To(BeEmpty())
+
+		pathNames, _ = BatchChanToPathNames(MustBatchScan(ScanBatches(context.TODO(), MustScanner(NewRecursiveScanner()), 4)))
+		Expect(pathNames).To(BeEmpty())
+	}))
+
+	t.Run("When internal scanner fails", ScannerTest(func(t *testing.T) {
+		for _, s := range []Scanner{
+			&FailingScanner{},
+			NewFilterRegularFilesScanner(&FailingScanner{}),
+			NewDebugScanner(&FailingScanner{}, func(FileItem) {}),
+			NewMultiScanner(MustScanner(NewBasicScanner(WithDir(dir))), &FailingScanner{}),
+		} {
+			batchChan, err := ScanBatches(context.TODO(), s, 4)
+			Expect(err).To(HaveOccurred())
+			Expect(batchChan).To(BeNil())
+		}
+	}))
+}
+
+func TestScanBatchesCancel(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-cancel")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceDir("level-0-directory-1", NewWorkspaceFiles("level-1-file-1", 50)...),
+		NewWorkspaceDir("level-0-directory-2", NewWorkspaceFiles("level-1-file-2", 50)...),
+	)).Purge()
+
+	scanners := map[string]func() Scanner{
+		"BasicScanner": func() Scanner {
+			return MustScanner(NewBasicScanner(WithDir(dir + "/level-0-directory-1")))
+		},
+		"parallel RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4)))
+		},
+		"depth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyDepthFirst)))
+		},
+		"FilterScanner": func() Scanner {
+			return NewFilterRegularFilesScanner(MustScanner(NewRecursiveScanner(WithDirectories(dir))))
+		},
+		"ordered MultiScanner": func() Scanner {
+			return NewOrderedMultiScanner(OrderLexical,
+				MustScanner(NewBasicScanner(WithDir(dir+"/level-0-directory-1"), WithOrder(OrderLexical))),
+				MustScanner(NewBasicScanner(WithDir(dir+"/level-0-directory-2"), WithOrder(OrderLexical))),
+			)
+		},
+	}
+
+	for name, newScanner := range scanners {
+		t.Run(fmt.Sprintf("When context of %s is cancelled", name), ScannerTest(func(t *testing.T) {
+			ExpectCancelToStopScan(UnbatchedScanner{newScanner(), 4})
+		}))
+	}
+}
@@ -1,5 +1,13 @@
 package scanner
 
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+var ErrRecursiveOnly = errors.New("option applies to the recursive mode only")
+
 type Mode int8
 
 const (
@@ -16,10 +24,32 @@ const (
 )
 
 type Builder struct {
-	mode        Mode
-	penetration Penetration
-	directories []string
-	filter      Filter
+	mode           Mode
+	penetration    Penetration
+	directories    []string
+	filter         Filter
+	globs          []string
+	sizes          []sizeExpr
+	times          []timeFilterFn
+	clock          Clock
+	find           string
+	maxDepth       uint
+	minDepth       uint
+	followSymlinks bool
+	sameFilesystem bool
+	prune          []Filter
+	gitignore      bool
+	gitExcludes    string
+	order          Order
+	dirEvents      bool
+	lazyStat       bool
+	getdents       bool
+	openat         bool
+	statx          bool
+	queueLimit     uint
+	queueSpill     bool
+	queueTempDir   string
+	fsys           fs.FS
 }
 
 func (b *Builder) Files() *Builder {
@@ -47,23 +77,231 @@ func (b *Builder) In(directories ...string) *Builder {
 	return b
 }
 
+func (b *Builder) MaxDepth(depth uint) *Builder {
+	b.maxDepth = depth
+	return b
+}
+
+func (b *Builder) MinDepth(depth uint) *Builder {
+	b.minDepth = depth
+	return b
+}
+
+func (b *Builder) FollowSymlinks() *Builder {
+	b.followSymlinks = true
+	return b
+}
+
+func (b *Builder) SameFilesystem() *Builder {
+	b.sameFilesystem = true
+	return b
+}
+
+func (b *Builder) Prune(filter Filter) *Builder {
+	b.prune = append(b.prune, filter)
+	return b
+}
+
+// Gitignore skips the files git ignores in the recursive mode, see WithGitignore.
+func (b *Builder) Gitignore() *Builder {
+	b.gitignore = true
+	return b
+}
+
+// GitExcludesFile reads the global excludes of git from the file, it implies Gitignore.
+func (b *Builder) GitExcludesFile(name string) *Builder {
+	b.gitignore = true
+	b.gitExcludes = name
+	return b
+}
+
+func (b *Builder) OrderBy(order Order) *Builder {
+	b.order = order
+	return b
+}
+
+func (b *Builder) DirEvents() *Builder {
+	b.dirEvents = true
+	return b
+}
+
+func (b *Builder) LazyStat() *Builder {
+	b.lazyStat = true
+	return b
+}
+
+func (b *Builder) Getdents() *Builder {
+	b.getdents = true
+	return b
+}
+
+func (b *Builder) Openat() *Builder {
+	b.openat = true
+	return b
+}
+
+func (b *Builder) Statx() *Builder {
+	b.statx = true
+	return b
+}
+
+func (b *Builder) QueueLimit(limit uint) *Builder {
+	b.queueLimit, b.queueSpill = limit, false
+	return b
+}
+
+func (b *Builder) QueueSpill(limit uint, tempDir string) *Builder {
+	b.queueLimit, b.queueSpill, b.queueTempDir = limit, true, tempDir
+	return b
+}
+
+// FS reads the directories through fsys instead of the OS, see WithFS.
+func (b *Builder) FS(fsys fs.FS) *Builder {
+	b.fsys = fsys
+	return b
+}
+
 func (b *Builder) Match(filter Filter) *Builder {
 	b.filter = filter
 	return b
 }
 
+// Glob matches the paths relative to the scan root against any of the patterns, see CompileGlob.
+// The recursive scan skips the directories none of the patterns can match below.
+func (b *Builder) Glob(patterns ...string) *Builder {
+	b.globs = append(b.globs, patterns...)
+	return b
+}
+
+// Size matches the files of the apparent size given by the expression, e.g. +100M, -1k or
+// 10KiB..2GiB, see ParseSizeFilter. Many sizes must all match.
+func (b *Builder) Size(expr string) *Builder {
+	b.sizes = append(b.sizes, sizeExpr{expr, SizeApparent})
+	return b
+}
+
+// AllocatedSize matches the files of the allocated size given by the expression, see Size.
+func (b *Builder) AllocatedSize(expr string) *Builder {
+	b.sizes = append(b.sizes, sizeExpr{expr, SizeAllocated})
+	return b
+}
+
+// sizeExpr is parsed once the scanner is built.
+type sizeExpr struct {
+	expr string
+	mode SizeMode
+}
+
+// timeFilterFn builds the time filter once the clock is known.
+type timeFilterFn func(clock Clock) (Filter, error)
+
+// TimeWithin matches the files which field is within the last d, see TimeWithinFilter.
+func (b *Builder) TimeWithin(field TimeField, d time.Duration) *Builder {
+	b.times = append(b.times, func(clock Clock) (Filter, error) {
+		return TimeWithinFilter(field, d, clock), nil
+	})
+	return b
+}
+
+// TimeOlderThan matches the files which field is older than d, see TimeOlderThanFilter.
+func (b *Builder) TimeOlderThan(field TimeField, d time.Duration) *Builder {
+	b.times = append(b.times, func(clock Clock) (Filter, error) {
+		return TimeOlderThanFilter(field, d, clock), nil
+	})
+	return b
+}
+
+func (b *Builder) TimeAfter(field TimeField, t time.Time) *Builder {
+	b.times = append(b.times, func(Clock) (Filter, error) {
+		return TimeAfterFilter(field, t), nil
+	})
+	return b
+}
+
+func (b *Builder) TimeBefore(field TimeField, t time.Time) *Builder {
+	b.times = append(b.times, func(Clock) (Filter, error) {
+		return TimeBeforeFilter(field, t), nil
+	})
+	return b
+}
+
+// NewerThanFile matches the files which field is after the one of the file, the file is stat-ed by
+// Build, through the FS when given.
+func (b *Builder) NewerThanFile(field TimeField, name string) *Builder {
+	b.times = append(b.times, func(Clock) (Filter, error) {
+		return timeNewerThanFileFilter(newFileSystem(b.fsys), field, name)
+	})
+	return b
+}
+
+// Find matches the items the find(1) expression is true for, see ParseFind. -prune prunes and
+// -mindepth & -maxdepth override MinDepth & MaxDepth, they need the recursive mode.
+func (b *Builder) Find(expr string) *Builder {
+	b.find = expr
+	return b
+}
+
+// Clock replaces time.Now for TimeWithin, TimeOlderThan & Find.
+func (b *Builder) Clock(clock Clock) *Builder {
+	b.clock = clock
+	return b
+}
+
 func (b *Builder) Build() (Scanner, error) {
 	var (
 		scanner Scanner
+		globs   []*Glob
+		filters []Filter
 		err     error
 	)
 
-	scanner, err = b.buildConcreteScanner()
+	for _, pattern := range b.globs {
+		g, err := CompileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		globs = append(globs, g)
+	}
+
+	for _, size := range b.sizes {
+		filter, err := ParseSizeFilter(size.expr, size.mode)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	clock := b.clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	for _, fn := range b.times {
+		filter, err := fn(clock)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	var find *FindExpr
+	if b.find != "" {
+		if find, err = ParseFindFS(b.find, clock, b.fsys); err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, find.Filter)
+	}
+
+	scanner, err = b.buildConcreteScanner(globs, find)
 	if err != nil {
 		return nil, err
 	}
 
-	scanner = b.buildFilterScanner(scanner)
+	scanner = b.buildFilterScanner(scanner, globs, filters)
 
 	return scanner, nil
 }
@@ -77,38 +315,168 @@ func (b *Builder) MustBuild() Scanner {
 	return s
 }
 
-func (b *Builder) buildConcreteScanner() (Scanner, error) {
+func (b *Builder) buildConcreteScanner(globs []*Glob, find *FindExpr) (Scanner, error) {
 	if b.penetration == PenetrationFlat {
+		if b.hasRecursiveOptions(find) {
+			return nil, ErrRecursiveOnly
+		}
+
+		options := b.buildScannerOptions()
+
 		switch len(b.directories) {
 		case 0:
-			return NewBasicScanner()
+			return NewBasicScanner(options...)
 		case 1:
-			return NewBasicScanner(WithDir(b.directories[0]))
+			return NewBasicScanner(append(options, WithDir(b.directories[0]))...)
 		default:
 			var scanners []Scanner
 			for _, d := range b.directories {
-				scanner, err := NewBasicScanner(WithDir(d))
+				scanner, err := NewBasicScanner(append(options[:len(options):len(options)], WithDir(d))...)
 				if err != nil {
 					return nil, err
 				}
 
 				scanners = append(scanners, scanner)
 			}
+
+			if b.order != OrderNone {
+				return NewOrderedMultiScanner(b.order, scanners...), nil
+			}
+
 			return NewMultiScanner(scanners...), nil
 		}
 	}
 
+	var options []RecursiveScannerOptionFn
+
 	switch len(b.directories) {
 	case 0:
-		return NewRecursiveScanner()
 	case 1:
-		return NewRecursiveScanner(WithDirectories(b.directories[0]))
+		options = append(options, WithDirectories(b.directories[0]))
+	default:
+		options = append(options, WithDirectories(b.directories...))
+	}
+
+	maxDepth, minDepth := b.maxDepth, b.minDepth
+	if find != nil && find.MaxDepth > 0 {
+		maxDepth = find.MaxDepth
+	}
+
+	if find != nil && find.MinDepth > 0 {
+		minDepth = find.MinDepth
+	}
+
+	if maxDepth > 0 {
+		options = append(options, WithMaxDepth(maxDepth))
+	}
+
+	if minDepth > 0 {
+		options = append(options, WithMinDepth(minDepth))
+	}
+
+	if b.followSymlinks {
+		options = append(options, WithFollowSymlinks())
+	}
+
+	if b.sameFilesystem {
+		options = append(options, WithSameFilesystem())
+	}
+
+	switch {
+	case b.gitExcludes != "":
+		options = append(options, WithGitExcludesFile(b.gitExcludes))
+	case b.gitignore:
+		options = append(options, WithGitignore())
+	}
+
+	switch {
+	case b.queueSpill:
+		options = append(options, WithQueueSpill(b.queueLimit, b.queueTempDir))
+	case b.queueLimit > 0:
+		options = append(options, WithQueueLimit(b.queueLimit))
+	}
+
+	if scannerOptions := b.buildScannerOptions(); len(scannerOptions) > 0 {
+		options = append(options, WithScannerOptions(scannerOptions...))
+	}
+
+	prune := b.prune[:len(b.prune):len(b.prune)]
+	if find != nil && find.Prune != nil {
+		prune = append(prune, find.Prune)
+	}
+
+	if len(globs) > 0 {
+		// directory is skipped only when none of the globs can match below it
+		var globPrunes []Filter
+		for _, g := range globs {
+			globPrunes = append(globPrunes, GlobPruneFilter(g))
+		}
+
+		prune = append(prune, AndFilter(globPrunes...))
+	}
+
+	switch len(prune) {
+	case 0:
+	case 1:
+		options = append(options, WithPrune(prune[0]))
 	default:
-		return NewRecursiveScanner(WithDirectories(b.directories...))
+		options = append(options, WithPrune(OrFilter(prune...)))
+	}
+
+	return NewRecursiveScanner(options...)
+}
+
+// hasRecursiveOptions tells whether any of the options the flat scan can't honour is set, they are
+// rejected rather than silently dropped.
+func (b *Builder) hasRecursiveOptions(find *FindExpr) bool {
+	if b.maxDepth > 0 || b.minDepth > 0 || b.followSymlinks || b.sameFilesystem || len(b.prune) > 0 {
+		return true
+	}
+
+	if b.gitignore || b.queueLimit > 0 || b.queueSpill {
+		return true
 	}
+
+	return find != nil && (find.MinDepth > 0 || find.MaxDepth > 0 || find.Prune != nil)
 }
 
-func (b *Builder) buildFilterScanner(scanner Scanner) Scanner {
+func (b *Builder) buildScannerOptions() []BasicScannerOptionFn {
+	var options []BasicScannerOptionFn
+
+	if b.order != OrderNone {
+		options = append(options, WithOrder(b.order))
+	}
+
+	if b.dirEvents {
+		options = append(options, WithDirEvents())
+	}
+
+	if b.lazyStat {
+		options = append(options, WithLazyStat())
+	}
+
+	if b.getdents {
+		options = append(options, WithGetdents())
+	}
+
+	if b.openat {
+		options = append(options, WithOpenat())
+	}
+
+	if b.statx {
+		options = append(options, WithStatx())
+	}
+
+	if b.fsys != nil {
+		options = append(options, WithFS(b.fsys))
+	}
+
+	return options
+}
+
+// buildFilterScanner ANDs the filters of the mode, Match & the globs with the extra ones. They never
+// drop the directory events.
+func (b *Builder) buildFilterScanner(scanner Scanner, globs []*Glob, extra []Filter) Scanner {
 	var filters []Filter
 
 	if b.mode == ModeFiles {
@@ -123,14 +491,37 @@ func (b *Builder) buildFilterScanner(scanner Scanner) Scanner {
 		filters = append(filters, b.filter)
 	}
 
+	switch len(globs) {
+	case 0:
+	case 1:
+		filters = append(filters, GlobFilter(globs[0]))
+	default:
+		var globFilters []Filter
+		for _, g := range globs {
+			globFilters = append(globFilters, GlobFilter(g))
+		}
+
+		filters = append(filters, OrFilter(globFilters...))
+	}
+
+	filters = append(filters, extra...)
+
+	var filter Filter
 	switch len(filters) {
 	case 0:
 		return scanner
 	case 1:
-		return NewFilterScanner(scanner, filters[0])
+		filter = filters[0]
 	default:
-		return NewFilterScanner(scanner, AndFilter(filters...))
+		filter = AndFilter(filters...)
 	}
+
+	// filters apply to the entries, the directory events always go through
+	if b.dirEvents {
+		filter = OrFilter(DirEventsFilter, filter)
+	}
+
+	return NewFilterScanner(scanner, filter)
 }
 
 func NewBuilder() *Builder {
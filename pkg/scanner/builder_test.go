@@ -1,8 +1,10 @@
 package scanner_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"testing/fstest"
 
 	"github.com/go-test/deep"
 	. "github.com/onsi/gomega"
@@ -95,6 +97,30 @@ func TestBuilder(t *testing.T) {
 			))
 		}))
 
+		t.Run("When flat mode is specified along with the recursive options", ScannerTest(func(t *testing.T) {
+			for _, b := range []*Builder{
+				NewBuilder().In("/tmp").MaxDepth(2),
+				NewBuilder().In("/tmp").MinDepth(3),
+				NewBuilder().In("/tmp").FollowSymlinks(),
+				NewBuilder().In("/tmp").SameFilesystem(),
+				NewBuilder().In("/tmp").Prune(PositiveFilter),
+				NewBuilder().In("/tmp").Gitignore(),
+				NewBuilder().In("/tmp").QueueLimit(10),
+				NewBuilder().In("/tmp").QueueSpill(10, ""),
+				NewBuilder().In("/tmp").Find("-maxdepth 2"),
+				NewBuilder().In("/tmp").Find("-mindepth 2"),
+				NewBuilder().In("/tmp").Find("-name x -prune"),
+			} {
+				scanner, err := b.Build()
+
+				Expect(err).To(Equal(ErrRecursiveOnly))
+				Expect(scanner).To(BeNil())
+			}
+
+			_, err := NewBuilder().In("/tmp").Find("-name x").Build()
+			Expect(err).ToNot(HaveOccurred())
+		}))
+
 		t.Run("When recursive mode is specified and no directory", ScannerTest(func(t *testing.T) {
 			scanner, err := NewBuilder().Recursive().Build()
 
@@ -140,6 +166,223 @@ func TestBuilder(t *testing.T) {
 		}))
 	}))
 
+	t.Run("Depth", ScannerTest(func(t *testing.T) {
+		t.Run("When max depth is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").MaxDepth(2).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithMaxDepth(2))),
+			))
+		}))
+
+		t.Run("When min depth is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").MinDepth(2).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithMinDepth(2))),
+			))
+		}))
+
+		t.Run("When min depth is greater than max depth", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().MinDepth(3).MaxDepth(2).Build()
+
+			Expect(err).To(HaveOccurred())
+			Expect(scanner).To(BeNil())
+		}))
+	}))
+
+	t.Run("When following symlinks is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").FollowSymlinks().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithFollowSymlinks())),
+		))
+	}))
+
+	t.Run("When same filesystem is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").SameFilesystem().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithSameFilesystem())),
+		))
+	}))
+
+	t.Run("When queue limit is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").QueueLimit(1000).Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithQueueLimit(1000))),
+		))
+	}))
+
+	t.Run("When queue spill is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").QueueSpill(1000, "/var/tmp").Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithQueueSpill(1000, "/var/tmp"))),
+		))
+	}))
+
+	t.Run("When lazy stat is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").LazyStat().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithLazyStat()))),
+		))
+	}))
+
+	t.Run("When getdents is specified", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").Getdents().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithGetdents()))),
+		))
+	}))
+
+	t.Run("When filesystem is specified", ScannerTest(func(t *testing.T) {
+		fsys := fstest.MapFS{"photos/a.jpg": {}}
+
+		scanner, err := NewBuilder().FS(fsys).In("photos").Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewBasicScanner(WithFS(fsys), WithDir("photos"))),
+		))
+
+		scanner, err = NewBuilder().Recursive().FS(fsys).In(".").Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(fsys)))),
+		))
+	}))
+
+	t.Run("Prune", ScannerTest(func(t *testing.T) {
+		t.Run("When single prune filter is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").Prune(PositiveFilter).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithPrune(PositiveFilter))),
+			))
+		}))
+
+		t.Run("When many prune filters are specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").Prune(PositiveFilter).Prune(NegativeFilter).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithPrune(OrFilter(PositiveFilter, NegativeFilter)))),
+			))
+		}))
+	}))
+
+	t.Run("Order", ScannerTest(func(t *testing.T) {
+		t.Run("When flat mode is specified and single directory", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Flat().In("/tmp").OrderBy(OrderLexical).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewBasicScanner(WithDir("/tmp"), WithOrder(OrderLexical))),
+			))
+		}))
+
+		t.Run("When flat mode is specified and many directories", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Flat().In("/tmp", "/var").OrderBy(OrderNatural).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				NewOrderedMultiScanner(
+					OrderNatural,
+					MustScanner(NewBasicScanner(WithDir("/tmp"), WithOrder(OrderNatural))),
+					MustScanner(NewBasicScanner(WithDir("/var"), WithOrder(OrderNatural))),
+				),
+			))
+		}))
+
+		t.Run("When recursive mode is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").OrderBy(OrderSize).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithOrder(OrderSize)))),
+			))
+		}))
+	}))
+
+	t.Run("Dir events", ScannerTest(func(t *testing.T) {
+		t.Run("When flat mode is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Flat().In("/tmp").DirEvents().Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewBasicScanner(WithDir("/tmp"), WithDirEvents())),
+			))
+		}))
+
+		t.Run("When recursive mode is specified along with the order", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Recursive().In("/tmp").DirEvents().OrderBy(OrderLexical).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithOrder(OrderLexical), WithDirEvents()))),
+			))
+		}))
+
+		t.Run("When filter is specified", ScannerTest(func(t *testing.T) {
+			scanner, err := NewBuilder().Files().DirEvents().Match(PositiveFilter).Build()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scanner).To(BeScanner(
+				NewFilterScanner(
+					MustScanner(NewBasicScanner(WithDirEvents())),
+					OrFilter(DirEventsFilter, AndFilter(RegularFilesFilter, PositiveFilter)),
+				),
+			))
+		}))
+
+		t.Run("When filter is applied", ScannerTest(func(t *testing.T) {
+			dir := NewDirectoryPath("directory-with-dir-events-filtered")
+			defer MustNewWorkspace(dir, WithItems(
+				NewWorkspaceFile("level-0-file-1.jpg"),
+				NewWorkspaceFile("level-0-file-2.txt"),
+				NewWorkspaceDir("level-0-directory-1",
+					NewWorkspaceFile("level-1-file-1.1.jpg"),
+				),
+			)).Purge()
+
+			fileChan, err := NewBuilder().Recursive().In(dir).DirEvents().OrderBy(OrderLexical).
+				Match(ExtensionFilter(".jpg")).MustBuild().Scan(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			var (
+				kinds     []ItemKind
+				pathNames []string
+			)
+
+			for _, item := range FileChanToSlice(fileChan) {
+				kinds = append(kinds, item.Kind)
+				pathNames = append(pathNames, item.FileInfo.PathName())
+			}
+
+			Expect(kinds).To(Equal([]ItemKind{KindDirStart, KindDirStart, KindEntry, KindDirEnd, KindEntry, KindDirEnd}))
+			Expect(pathNames).To(Equal([]string{
+				dir,
+				dir + "/level-0-directory-1",
+				dir + "/level-0-directory-1/level-1-file-1.1.jpg",
+				dir + "/level-0-directory-1",
+				dir + "/level-0-file-1.jpg",
+				dir,
+			}))
+		}))
+	}))
+
 	t.Run("Filter", ScannerTest(func(t *testing.T) {
 		t.Run("Single Filter", ScannerTest(func(t *testing.T) {
 			scanner, err := NewBuilder().Match(PositiveFilter).Build()
@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"iter"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotResumable       = errors.New("scanner cannot be resumed")
+	ErrInvalidCursorToken = errors.New("invalid cursor token")
+)
+
+// Cursor pulls the files out of the scanner one by one. Directory events are skipped, the same way
+// All does. Cursor is not safe for concurrent use.
+type Cursor struct {
+	ctx       context.Context
+	next      func() (FileItem, bool)
+	stop      func()
+	item      FileItem
+	err       error
+	traversal traversal
+	resumable bool
+	position  position
+}
+
+// NewCursor starts scanning from the beginning.
+func NewCursor(ctx context.Context, s Scanner) *Cursor {
+	c, _ := ResumeCursor(ctx, s, "")
+	return c
+}
+
+// ResumeCursor starts scanning right after the position the token has been taken at. The token has
+// to come from the same kind of scan, an empty one starts from the beginning. The scan is resumed
+// by the position, not by the number of items, so the files added or removed in the meantime don't
+// make it skip or repeat any other file.
+func ResumeCursor(ctx context.Context, s Scanner, token string) (*Cursor, error) {
+	c := &Cursor{ctx: ctx}
+	c.traversal, c.resumable = traversalOf(s)
+
+	if token != "" {
+		if !c.resumable {
+			return nil, ErrNotResumable
+		}
+
+		t, err := decodeCursorToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Traversal != c.traversal {
+			return nil, ErrInvalidCursorToken
+		}
+
+		c.position = t.Position
+	}
+
+	seq := items(ctx, s)
+	if c.resumable {
+		seq = s.(seeker).seek(ctx, c.position)
+	}
+
+	c.next, c.stop = iter.Pull(seq)
+
+	return c, nil
+}
+
+// Next advances the cursor to the next file. It returns false once the scan is done, the context is
+// done or the cursor is closed.
+func (c *Cursor) Next() bool {
+	if c.next == nil {
+		return false
+	}
+
+	for {
+		item, ok := c.next()
+		if !ok {
+			c.err = c.ctx.Err()
+			c.Close()
+			return false
+		}
+
+		if item.Kind != KindEntry {
+			continue
+		}
+
+		c.item = item
+		if item.FileInfo != nil {
+			c.position = c.traversal.position(item.FileInfo)
+		}
+
+		return true
+	}
+}
+
+// Item is the file the cursor points at. Like the items of the channel it carries the error, when
+// the file could not be read.
+func (c *Cursor) Item() FileItem {
+	return c.item
+}
+
+// Err is the error which stopped the cursor before the end of the scan.
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Close stops the scanner and all its goroutines.
+func (c *Cursor) Close() error {
+	if c.next != nil {
+		c.stop()
+		c.next, c.stop = nil, nil
+	}
+
+	return nil
+}
+
+// Token is the position of the current file, ResumeCursor continues with the file right after it.
+func (c *Cursor) Token() (string, error) {
+	if !c.resumable {
+		return "", ErrNotResumable
+	}
+
+	if c.position == (position{}) {
+		return "", nil
+	}
+
+	data, err := json.Marshal(cursorToken{c.traversal, c.position})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+type cursorToken struct {
+	Traversal traversal `json:"t"`
+	Position  position  `json:"p"`
+}
+
+func decodeCursorToken(token string) (cursorToken, error) {
+	var t cursorToken
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, ErrInvalidCursorToken
+	}
+
+	if err := json.Unmarshal(data, &t); err != nil || t.Position.PathName == "" {
+		return t, ErrInvalidCursorToken
+	}
+
+	return t, nil
+}
+
+// seeker is implemented by the scanners emitting the items in a deterministic order, so the scan
+// can be resumed after any of them.
+type seeker interface {
+	// traversal returns false when the order is not deterministic
+	traversal() (traversal, bool)
+	// seek iterates over the items placed after the position, the zero one is the beginning
+	seek(ctx context.Context, pos position) iter.Seq[FileItem]
+}
+
+func traversalOf(s Scanner) (traversal, bool) {
+	if sk, ok := s.(seeker); ok {
+		return sk.traversal()
+	}
+
+	return traversal{}, false
+}
+
+const (
+	traversalDirectory    = "directory"
+	traversalDepthFirst   = "depth-first"
+	traversalBreadthFirst = "breadth-first"
+)
+
+// traversal is the order the scanner emits the items in.
+type traversal struct {
+	Kind  string `json:"k"`
+	Order Order  `json:"o"`
+}
+
+// compare compares the file with the position, in the order of the traversal.
+func (t traversal) compare(info FileInfo, pos position) int {
+	switch t.Kind {
+	case traversalDirectory:
+		return t.Order.compareFileInfos(info, positionInfo{pos})
+	case traversalBreadthFirst:
+		if c := compareInt64(int64(info.Depth()), int64(pos.Depth)); c != 0 {
+			return c
+		}
+	}
+
+	return t.Order.comparePaths(info.PathName(), pos.PathName)
+}
+
+// seek walks the scanner, but emits the items placed after the position only. Depth-first traversal
+// does not even read the directories placed entirely before the position.
+func (t traversal) seek(ctx context.Context, w walker, pos position) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		emit := yieldEmit(yield)
+		if pos == (position{}) {
+			w.walk(ctx, emit)
+			return
+		}
+
+		// errors go right after the file preceding them
+		var after bool
+
+		w.walk(ctx, func(item FileItem) walkAction {
+			if item.Kind == KindEntry && item.FileInfo != nil {
+				c := t.compare(item.FileInfo, pos)
+				after = c > 0
+
+				if !after {
+					if t.Kind == traversalDepthFirst && c < 0 && item.FileInfo.IsDir() &&
+						!strings.HasPrefix(pos.PathName, item.FileInfo.PathName()+"/") {
+						return walkSkipDir
+					}
+
+					return walkContinue
+				}
+			}
+
+			if !after {
+				return walkContinue
+			}
+
+			return emit(item)
+		})
+	}
+}
+
+// position is the file the cursor has returned lately, the zero one is the beginning of the scan.
+type position struct {
+	PathName string `json:"p,omitempty"`
+	Depth    uint   `json:"d,omitempty"`
+	Size     int64  `json:"s,omitempty"`
+	ModTime  int64  `json:"m,omitempty"`
+}
+
+// position of the file holds what the traversal compares, so the file is not stat-ed needlessly.
+func (t traversal) position(info FileInfo) position {
+	pos := position{PathName: info.PathName(), Depth: info.Depth()}
+
+	switch t.Order {
+	case OrderSize:
+		pos.Size = info.Size()
+	case OrderModTime:
+		pos.ModTime = info.ModTime().UnixNano()
+	}
+
+	return pos
+}
+
+// positionInfo lets the position be compared the same way the siblings are sorted.
+type positionInfo struct {
+	position
+}
+
+func (i positionInfo) Name() string       { return path.Base(i.PathName) }
+func (i positionInfo) Size() int64        { return i.position.Size }
+func (i positionInfo) Mode() os.FileMode  { return 0 }
+func (i positionInfo) ModTime() time.Time { return time.Unix(0, i.position.ModTime) }
+func (i positionInfo) IsDir() bool        { return false }
+func (i positionInfo) Sys() interface{}   { return nil }
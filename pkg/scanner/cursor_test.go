@@ -0,0 +1,257 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// CursorPathNames reads the cursor till the end and closes it.
+func CursorPathNames(c *Cursor) []string {
+	defer c.Close()
+
+	var pathNames []string
+	for c.Next() {
+		Expect(c.Item().Err).ToNot(HaveOccurred())
+		pathNames = append(pathNames, c.Item().String())
+	}
+
+	Expect(c.Err()).ToNot(HaveOccurred())
+	return pathNames
+}
+
+// PagedPathNames reads the scanner page by page, every page resumed by the new cursor.
+func PagedPathNames(s Scanner, pageSize int) []string {
+	var (
+		pathNames []string
+		token     string
+	)
+
+	for {
+		c, err := ResumeCursor(context.TODO(), s, token)
+		Expect(err).ToNot(HaveOccurred())
+
+		var page int
+		for page < pageSize && c.Next() {
+			pathNames = append(pathNames, c.Item().String())
+			page++
+		}
+
+		token, err = c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		if page < pageSize {
+			return pathNames
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-page")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFiles("level-2-file-1.2", 3)...,
+			),
+		),
+		NewWorkspaceDir("level-0-directory-1-b",
+			NewWorkspaceFiles("level-1-file-1-b", 2)...,
+		),
+		NewWorkspaceDir("level-0-directory-2",
+			NewWorkspaceFiles("level-1-file-2", 5)...,
+		),
+	)).Purge()
+
+	scanners := map[string]func() Scanner{
+		"BasicScanner": func() Scanner {
+			return MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical)))
+		},
+		"BasicScanner sorted by size": func() Scanner {
+			return MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderSize)))
+		},
+		"depth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithStrategy(StrategyDepthFirst),
+				WithScannerOptions(WithOrder(OrderLexical), WithDirEvents()),
+			))
+		},
+		"breadth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithStrategy(StrategyBreadthFirst),
+				WithScannerOptions(WithOrder(OrderNatural)),
+			))
+		},
+		"sorted parallel RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithWorkers(4),
+				WithScannerOptions(WithOrder(OrderLexical)),
+			))
+		},
+		"FilterScanner": func() Scanner {
+			return NewFilterRegularFilesScanner(MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithScannerOptions(WithOrder(OrderLexical)),
+			)))
+		},
+		"DebugScanner": func() Scanner {
+			return NewDebugScanner(MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithStrategy(StrategyBreadthFirst),
+				WithScannerOptions(WithOrder(OrderLexical)),
+			)), func(FileItem) {})
+		},
+	}
+
+	for name, newScanner := range scanners {
+		t.Run(fmt.Sprintf("When paging %s", name), ScannerTest(func(t *testing.T) {
+			expected := EntryPathNames(MustScan(newScanner().Scan(context.TODO())))
+			Expect(expected).ToNot(BeEmpty())
+
+			Expect(CursorPathNames(NewCursor(context.TODO(), newScanner()))).To(Equal(expected))
+			for _, pageSize := range []int{1, 2, 3, 100} {
+				Expect(PagedPathNames(newScanner(), pageSize)).To(Equal(expected))
+			}
+		}))
+	}
+
+	t.Run("When files are removed and added before resuming", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical))))
+
+		c := NewCursor(context.TODO(), s)
+		for i := 0; i < 2; i++ {
+			Expect(c.Next()).To(BeTrue())
+		}
+
+		Expect(c.Item().FileInfo.RelPath()).To(Equal("level-0-directory-1/level-1-directory-1.2"))
+		token, err := c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		removed := path.Join(dir, "level-0-directory-1/level-1-directory-1.2/level-2-file-1.2-0")
+		added := path.Join(dir, "level-0-directory-1/level-1-directory-1.2/level-2-file-1.2-00")
+		Expect(os.Rename(removed, added)).To(Succeed())
+		defer os.Rename(added, removed)
+
+		c, err = ResumeCursor(context.TODO(), s, token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Next()).To(BeTrue())
+		Expect(c.Item().String()).To(Equal(added))
+		Expect(c.Next()).To(BeTrue())
+		Expect(c.Item().FileInfo.RelPath()).To(Equal("level-0-directory-1/level-1-directory-1.2/level-2-file-1.2-1"))
+		Expect(c.Close()).To(Succeed())
+	}))
+
+	t.Run("When position has been removed before resuming", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewBasicScanner(WithDir(path.Join(dir, "level-0-directory-2")), WithOrder(OrderLexical)))
+
+		c := NewCursor(context.TODO(), s)
+		Expect(c.Next()).To(BeTrue())
+		Expect(c.Next()).To(BeTrue())
+		token, err := c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		removed := c.Item().String()
+		Expect(os.Rename(removed, removed+".bak")).To(Succeed())
+		defer os.Rename(removed+".bak", removed)
+
+		c, err = ResumeCursor(context.TODO(), s, token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(CursorPathNames(c)).To(Equal([]string{
+			removed + ".bak",
+			path.Join(dir, "level-0-directory-2", "level-1-file-2-2"),
+			path.Join(dir, "level-0-directory-2", "level-1-file-2-3"),
+			path.Join(dir, "level-0-directory-2", "level-1-file-2-4"),
+		}))
+	}))
+
+	t.Run("When scanner is not resumable", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4)))
+
+		c := NewCursor(context.TODO(), s)
+		Expect(SortedPathNames(CursorPathNames(c))).To(Equal(SortedPathNames(EntryPathNames(MustScan(s.Scan(context.TODO()))))))
+
+		_, err := c.Token()
+		Expect(err).To(Equal(ErrNotResumable))
+
+		sorted := MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical)))
+		c = NewCursor(context.TODO(), sorted)
+		Expect(c.Next()).To(BeTrue())
+		token, err := c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		_, err = ResumeCursor(context.TODO(), s, token)
+		Expect(err).To(Equal(ErrNotResumable))
+	}))
+
+	t.Run("When token is invalid", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical))))
+
+		_, err := ResumeCursor(context.TODO(), s, "not a token")
+		Expect(err).To(Equal(ErrInvalidCursorToken))
+
+		other := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithStrategy(StrategyBreadthFirst),
+			WithScannerOptions(WithOrder(OrderLexical)),
+		))
+		c := NewCursor(context.TODO(), other)
+		Expect(c.Next()).To(BeTrue())
+		token, err := c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		_, err = ResumeCursor(context.TODO(), s, token)
+		Expect(err).To(Equal(ErrInvalidCursorToken))
+	}))
+
+	t.Run("When nothing has been read yet", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical)))
+
+		c := NewCursor(context.TODO(), s)
+		defer c.Close()
+
+		token, err := c.Token()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token).To(BeEmpty())
+	}))
+
+	t.Run("When cursor is closed", ScannerTest(func(t *testing.T) {
+		goroutines := runtime.NumGoroutine()
+
+		c := NewCursor(context.TODO(), MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4))))
+		Expect(c.Next()).To(BeTrue())
+		Expect(c.Close()).To(Succeed())
+		Expect(c.Next()).To(BeFalse())
+		Expect(c.Err()).ToNot(HaveOccurred())
+
+		Eventually(runtime.NumGoroutine, time.Second).Should(BeNumerically("<=", goroutines))
+	}))
+
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		c := NewCursor(ctx, MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))))
+		Expect(c.Next()).To(BeTrue())
+
+		cancel()
+		for c.Next() {
+		}
+
+		Expect(c.Err()).To(Equal(context.Canceled))
+	}))
+}
@@ -3,6 +3,7 @@ package scanner
 import (
 	"context"
 	"fmt"
+	"iter"
 )
 
 type DebugFn func(item FileItem)
@@ -25,13 +26,77 @@ func (s *DebugScanner) Scan(ctx context.Context) (FileItemChan, error) {
 
 		for item := range innerFileChan {
 			s.debugFn(item)
-			fileChan <- item
+			if !send(ctx, fileChan, item) {
+				return
+			}
 		}
 	}()
 
 	return fileChan, nil
 }
 
+func (s *DebugScanner) ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error) {
+	innerBatchChan, err := ScanBatches(ctx, s.scanner, size)
+	if err != nil {
+		return nil, err
+	}
+
+	batchChan := make(FileItemBatchChan)
+
+	go func() {
+		defer close(batchChan)
+
+		for batch := range innerBatchChan {
+			for _, item := range batch {
+				s.debugFn(item)
+			}
+
+			if !sendBatch(ctx, batchChan, batch) {
+				return
+			}
+		}
+	}()
+
+	return batchChan, nil
+}
+
+func (s *DebugScanner) All(ctx context.Context) iter.Seq2[FileInfo, error] {
+	return entries(s.items(ctx))
+}
+
+func (s *DebugScanner) items(ctx context.Context) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		for item := range items(ctx, s.scanner) {
+			s.debugFn(item)
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func (s *DebugScanner) walk(ctx context.Context, emit emitFn) {
+	walk(ctx, s.scanner, func(item FileItem) walkAction {
+		s.debugFn(item)
+		return emit(item)
+	})
+}
+
+func (s *DebugScanner) traversal() (traversal, bool) {
+	return traversalOf(s.scanner)
+}
+
+func (s *DebugScanner) seek(ctx context.Context, pos position) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		for item := range s.scanner.(seeker).seek(ctx, pos) {
+			s.debugFn(item)
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
 func NewPrintPathNameDebugScanner(scanner Scanner) *DebugScanner {
 	return NewDebugScanner(scanner, func(item FileItem) {
 		fmt.Println(item.String())
@@ -39,6 +39,16 @@ func TestDebugScanner_Scan(t *testing.T) {
 	}))
 }
 
+func TestDebugScannerCancel(t *testing.T) {
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-cancel")
+		defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", 20)...)).Purge()
+
+		innerScanner := scanner.MustScanner(scanner.NewBasicScanner(scanner.WithDir(dir)))
+		ExpectCancelToStopScan(scanner.NewDebugScanner(innerScanner, func(scanner.FileItem) {}))
+	}))
+}
+
 func TestNewPrintPathNameDebugScanner(t *testing.T) {
 	t.Run("Scanner should not panic", ScannerTest(func(t *testing.T) {
 		internalScanner := &SuccessfulScanner{
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package scanner
+
+import "os"
+
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+func fileID(info os.FileInfo) (uint64, uint64, bool) {
+	return 0, 0, false
+}
+
+func allocatedBlocks(info os.FileInfo) (int64, bool) {
+	return 0, false
+}
+
+func fileOwner(info os.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+func deviceID(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(stat.Dev), true
+}
+
+// fileID is the device & the inode identifying the file, whatever wraps its FileInfo.
+func fileID(info os.FileInfo) (uint64, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
+
+// allocatedBlocks is the number of the 512-byte blocks allocated for the file.
+func allocatedBlocks(info os.FileInfo) (int64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+
+	return int64(stat.Blocks), true
+}
+
+// fileOwner is the user & the group owning the file.
+func fileOwner(info os.FileInfo) (uint32, uint32, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return uint32(stat.Uid), uint32(stat.Gid), true
+}
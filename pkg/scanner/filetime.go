@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const nameTimeFilter = "TimeFilter"
+
+var ErrTimeNotSupported = errors.New("timestamp is not supported")
+
+// TimeField is the timestamp of the file the time filters compare.
+type TimeField int8
+
+const (
+	// TimeModified is the last change of the content, FileInfo.ModTime
+	TimeModified TimeField = iota
+	// TimeAccessed is the last read of the content, it needs the Stat_t of Unix or WithStatx
+	TimeAccessed
+	// TimeChanged is the last change of the content or the metadata, it needs the same as TimeAccessed
+	TimeChanged
+	// TimeBirth is the creation of the file, it needs WithStatx on Linux, or the Stat_t of macOS and BSDs
+	TimeBirth
+)
+
+// Clock tells the current time, the relative time filters are evaluated against it. It's time.Now
+// unless the tests need it to stand still.
+type Clock func() time.Time
+
+// TimeAfterFilter matches the files which field is after t.
+func TimeAfterFilter(field TimeField, t time.Time) Filter {
+	return timeFilter(field, func(ft time.Time) bool {
+		return ft.After(t)
+	})
+}
+
+// TimeBeforeFilter matches the files which field is before t.
+func TimeBeforeFilter(field TimeField, t time.Time) Filter {
+	return timeFilter(field, func(ft time.Time) bool {
+		return ft.Before(t)
+	})
+}
+
+// TimeBetweenFilter matches the files which field is from inclusive to to exclusive.
+func TimeBetweenFilter(field TimeField, from, to time.Time) Filter {
+	return timeFilter(field, func(ft time.Time) bool {
+		return !ft.Before(from) && ft.Before(to)
+	})
+}
+
+// TimeWithinFilter matches the files which field is within the last d, e.g. modified in the last 24h.
+func TimeWithinFilter(field TimeField, d time.Duration, clock Clock) Filter {
+	return timeFilter(field, func(ft time.Time) bool {
+		return !ft.Before(clock().Add(-d))
+	})
+}
+
+// TimeOlderThanFilter matches the files which field is older than d, e.g. not accessed for 30 days.
+func TimeOlderThanFilter(field TimeField, d time.Duration, clock Clock) Filter {
+	return timeFilter(field, func(ft time.Time) bool {
+		return ft.Before(clock().Add(-d))
+	})
+}
+
+// TimeNewerThanFileFilter matches the files which field is after the same field of the OS file, the
+// way find -newer does it. The OS file is stated by statx where available, so TimeBirth works on
+// Linux too.
+func TimeNewerThanFileFilter(field TimeField, name string) (Filter, error) {
+	return timeNewerThanFileFilter(osFileSystem{}, field, name)
+}
+
+// timeNewerThanFileFilter is TimeNewerThanFileFilter with the file read through fsys, see WithFS.
+func timeNewerThanFileFilter(fsys fileSystem, field TimeField, name string) (Filter, error) {
+	var (
+		info os.FileInfo
+		err  = ErrStatxNotSupported
+	)
+
+	if fsys == (osFileSystem{}) {
+		info, err = statxFollow(name)
+	}
+
+	if errors.Is(err, ErrStatxNotSupported) {
+		info, err = fsys.Stat(name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := fileTime(info, field)
+	if !ok {
+		return nil, ErrTimeNotSupported
+	}
+
+	return TimeAfterFilter(field, t), nil
+}
+
+func timeFilter(field TimeField, match func(ft time.Time) bool) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		// file without the timestamp never matches
+		ft, ok := fileTime(file.FileInfo, field)
+		return ok && match(ft)
+	}), nameTimeFilter)
+}
+
+func fileTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	if field == TimeModified {
+		return info.ModTime(), true
+	}
+
+	if stx, ok := StatxOf(info); ok && field == TimeBirth {
+		birth := stx.BirthTime()
+		return birth, !birth.IsZero()
+	}
+
+	return statTime(info, field)
+}
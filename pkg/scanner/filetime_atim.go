@@ -0,0 +1,26 @@
+//go:build linux || openbsd || dragonfly || solaris || aix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch field {
+	case TimeAccessed:
+		return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), true
+	case TimeChanged:
+		return time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec)), true
+	}
+
+	// birth time is known to statx only
+	return time.Time{}, false
+}
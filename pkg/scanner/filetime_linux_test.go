@@ -0,0 +1,88 @@
+package scanner_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestTimeFilterStat(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		accessed = filepath.Join(dir, "accessed.txt")
+		stale    = filepath.Join(dir, "stale.txt")
+		now      = time.Now()
+	)
+
+	for _, name := range []string{accessed, stale} {
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// mtime is the same, atime differs
+	if err := os.Chtimes(accessed, now, now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(stale, now.Add(-48*time.Hour), now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	scan := func(filter Filter, options ...BasicScannerOptionFn) []string {
+		s := NewFilterScanner(MustScanner(NewBasicScanner(append(options, WithDir(dir))...)), filter)
+		return SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))
+	}
+
+	for name, options := range map[string][]BasicScannerOptionFn{
+		"lstat":     nil,
+		"lazy stat": {WithLazyStat()},
+		"statx":     {WithStatx()},
+	} {
+		t.Run("When scanned with "+name, ScannerTest(func(t *testing.T) {
+			Expect(scan(TimeWithinFilter(TimeAccessed, 24*time.Hour, time.Now), options...)).To(Equal([]string{accessed}))
+			Expect(scan(TimeOlderThanFilter(TimeAccessed, 24*time.Hour, time.Now), options...)).To(Equal([]string{stale}))
+			Expect(scan(TimeWithinFilter(TimeModified, 24*time.Hour, time.Now), options...)).To(BeEmpty())
+
+			// ctime is set by the kernel whenever the times change
+			Expect(scan(TimeWithinFilter(TimeChanged, time.Hour, time.Now), options...)).To(Equal([]string{accessed, stale}))
+		}))
+	}
+
+	t.Run("When birth time is needed", ScannerTest(func(t *testing.T) {
+		Expect(scan(TimeWithinFilter(TimeBirth, time.Hour, time.Now))).To(BeEmpty())
+
+		// not every filesystem records it
+		if stx, ok := StatxOf(MustStatx(accessed)); !ok || stx.BirthTime().IsZero() {
+			t.Skip("birth time is not supported")
+		}
+
+		Expect(scan(TimeWithinFilter(TimeBirth, time.Hour, time.Now), WithStatx())).To(Equal([]string{accessed, stale}))
+
+		// the timestamps are coarse, the newer file has to be created a while later
+		time.Sleep(20 * time.Millisecond)
+		newer := filepath.Join(dir, "newer.txt")
+		Expect(os.WriteFile(newer, nil, 0644)).To(Succeed())
+
+		filter, err := TimeNewerThanFileFilter(TimeBirth, stale)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scan(filter, WithStatx())).To(Equal([]string{newer}))
+	}))
+}
+
+// MustStatx returns the FileInfo the scanner WithStatx gives for the file.
+func MustStatx(name string) os.FileInfo {
+	s := MustScanner(NewBasicScanner(WithDir(filepath.Dir(name)), WithStatx()))
+	for item := range MustScan(s.Scan(context.TODO())) {
+		if item.FileInfo != nil && item.FileInfo.PathName() == name {
+			return item.FileInfo
+		}
+	}
+
+	panic(name + " not found")
+}
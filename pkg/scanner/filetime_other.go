@@ -0,0 +1,12 @@
+//go:build !unix
+
+package scanner
+
+import (
+	"os"
+	"time"
+)
+
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	return time.Time{}, false
+}
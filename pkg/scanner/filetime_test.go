@@ -0,0 +1,106 @@
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestTimeFilter(t *testing.T) {
+	var (
+		now   = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+		clock = func() time.Time { return now }
+		fsys  = fstest.MapFS{
+			"hour-ago.txt":   {ModTime: now.Add(-time.Hour)},
+			"day-ago.txt":    {ModTime: now.Add(-25 * time.Hour)},
+			"month-ago.txt":  {ModTime: now.AddDate(0, -1, 0)},
+			"year-2024.txt":  {ModTime: time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)},
+			"year-2025.txt":  {ModTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			"from-future.md": {ModTime: now.Add(time.Hour)},
+		}
+	)
+
+	scan := func(filter Filter) []string {
+		s := NewFilterScanner(MustScanner(NewBasicScanner(WithFS(fsys), WithDir("."))), filter)
+		return SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))
+	}
+
+	t.Run("When relative filters are applied", ScannerTest(func(t *testing.T) {
+		Expect(scan(TimeWithinFilter(TimeModified, 24*time.Hour, clock))).To(Equal([]string{"from-future.md", "hour-ago.txt"}))
+		Expect(scan(TimeOlderThanFilter(TimeModified, 24*time.Hour, clock))).To(Equal([]string{"day-ago.txt", "month-ago.txt", "year-2024.txt", "year-2025.txt"}))
+	}))
+
+	t.Run("When clock moves on", ScannerTest(func(t *testing.T) {
+		current := now
+		filter := TimeWithinFilter(TimeModified, 24*time.Hour, func() time.Time { return current })
+
+		Expect(scan(filter)).To(ContainElement("hour-ago.txt"))
+
+		current = now.Add(24 * time.Hour)
+		Expect(scan(filter)).To(Equal([]string{"from-future.md"}))
+	}))
+
+	t.Run("When absolute filters are applied", ScannerTest(func(t *testing.T) {
+		since2025 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		Expect(scan(TimeBeforeFilter(TimeModified, since2025))).To(Equal([]string{"year-2024.txt"}))
+		Expect(scan(TimeAfterFilter(TimeModified, now))).To(Equal([]string{"from-future.md"}))
+		Expect(scan(TimeBetweenFilter(TimeModified, since2025, now.AddDate(0, -1, 0)))).To(Equal([]string{"year-2025.txt"}))
+		Expect(scan(AndFilter(
+			TimeAfterFilter(TimeModified, since2025),
+			TimeBeforeFilter(TimeModified, now),
+		))).To(Equal([]string{"day-ago.txt", "hour-ago.txt", "month-ago.txt"}))
+	}))
+
+	t.Run("When timestamp is unknown", ScannerTest(func(t *testing.T) {
+		// fs.FS has no access time, the files never match then
+		Expect(scan(TimeOlderThanFilter(TimeAccessed, 0, clock))).To(BeEmpty())
+		Expect(scan(TimeWithinFilter(TimeBirth, 1000*time.Hour, clock))).To(BeEmpty())
+	}))
+
+	t.Run("When filter is newer than file", ScannerTest(func(t *testing.T) {
+		reference := filepath.Join(t.TempDir(), "reference")
+		Expect(os.WriteFile(reference, nil, 0644)).To(Succeed())
+		Expect(os.Chtimes(reference, now, now.Add(-2*time.Hour))).To(Succeed())
+
+		filter, err := TimeNewerThanFileFilter(TimeModified, reference)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scan(filter)).To(Equal([]string{"from-future.md", "hour-ago.txt"}))
+
+		_, err = TimeNewerThanFileFilter(TimeModified, reference+".missing")
+		Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+	}))
+
+	t.Run("When builder is given the times", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewBuilder().Flat().FS(fsys).In(".").
+			Clock(clock).
+			TimeOlderThan(TimeModified, time.Hour).
+			TimeAfter(TimeModified, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).
+			MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{"day-ago.txt", "month-ago.txt"}))
+
+		fileChan, err = NewBuilder().Flat().FS(fsys).In(".").Clock(clock).TimeWithin(TimeModified, 2*time.Hour).TimeBefore(TimeModified, now).MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(FileChanToPathNames(fileChan)).To(Equal([]string{"hour-ago.txt"}))
+
+		_, err = NewBuilder().Flat().In(".").NewerThanFile(TimeModified, filepath.Join(t.TempDir(), "missing")).Build()
+		Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+
+		// the reference is read through the FS as well
+		fileChan, err = NewBuilder().Flat().FS(fsys).In(".").NewerThanFile(TimeModified, "day-ago.txt").MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{"from-future.md", "hour-ago.txt"}))
+	}))
+}
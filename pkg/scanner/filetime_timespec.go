@@ -0,0 +1,27 @@
+//go:build darwin || freebsd || netbsd
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch field {
+	case TimeAccessed:
+		return time.Unix(stat.Atimespec.Unix()), true
+	case TimeChanged:
+		return time.Unix(stat.Ctimespec.Unix()), true
+	case TimeBirth:
+		return time.Unix(stat.Birthtimespec.Unix()), true
+	}
+
+	return time.Time{}, false
+}
@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"context"
+	"iter"
 	"regexp"
 	"strings"
 )
@@ -11,15 +12,20 @@ const (
 	nameRegExpFilter       = "RegExpFilter"
 	nameAndFilter          = "AndFilter"
 	nameOrFilter           = "OrFilter"
+	nameNotFilter          = "NotFilter"
 	nameRegularFilesFilter = "RegularFilesFilter"
 	nameDirectoriesFilter  = "DirectoriesFilter"
 	nameErrFilter          = "ErrFilter"
+	nameDirEventsFilter    = "DirEventsFilter"
 )
 
 var (
 	DirectoriesFilter  = MakeNamedFilter(FilterFn(filterDirectoriesFn), nameDirectoriesFilter)
 	RegularFilesFilter = MakeNamedFilter(FilterFn(filterRegularFilesFn), nameRegularFilesFilter)
 	ErrFilter          = MakeNamedFilter(FilterFn(filterErrorsFn), nameErrFilter)
+	// DirEventsFilter matches the KindDirStart & KindDirEnd items, OR it with the filter of the
+	// entries to keep the events, see WithDirEvents
+	DirEventsFilter = MakeNamedFilter(FilterFn(filterDirEventsFn), nameDirEventsFilter)
 )
 
 type Filter interface {
@@ -101,12 +107,18 @@ func OrFilter(filters ...Filter) Filter {
 	}), nameOrFilter)
 }
 
+func NotFilter(filter Filter) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		return !filter.Match(file)
+	}), nameNotFilter)
+}
+
 func filterRegularFilesFn(f FileItem) bool {
 	if f.FileInfo == nil {
 		return false
 	}
 
-	return f.FileInfo.Mode().IsRegular()
+	return fileType(f.FileInfo).IsRegular()
 }
 
 func filterDirectoriesFn(f FileItem) bool {
@@ -114,7 +126,7 @@ func filterDirectoriesFn(f FileItem) bool {
 		return false
 	}
 
-	return f.FileInfo.Mode().IsDir()
+	return fileType(f.FileInfo).IsDir()
 }
 
 func filterErrorsFn(f FileItem) bool {
@@ -141,13 +153,83 @@ func (s *FilterScanner) Scan(ctx context.Context) (FileItemChan, error) {
 			if !s.filter.Match(item) {
 				continue
 			}
-			fileChan <- item
+
+			if !send(ctx, fileChan, item) {
+				return
+			}
 		}
 	}()
 
 	return fileChan, nil
 }
 
+// ScanBatches filters the batches of the scanner in place, so they may get smaller than the size.
+func (s *FilterScanner) ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error) {
+	innerBatchChan, err := ScanBatches(ctx, s.scanner, size)
+	if err != nil {
+		return nil, err
+	}
+
+	batchChan := make(FileItemBatchChan)
+
+	go func() {
+		defer close(batchChan)
+
+		for batch := range innerBatchChan {
+			matched := batch[:0]
+			for _, item := range batch {
+				if s.filter.Match(item) {
+					matched = append(matched, item)
+				}
+			}
+
+			if len(matched) > 0 && !sendBatch(ctx, batchChan, matched) {
+				return
+			}
+		}
+	}()
+
+	return batchChan, nil
+}
+
+func (s *FilterScanner) All(ctx context.Context) iter.Seq2[FileInfo, error] {
+	return entries(s.items(ctx))
+}
+
+func (s *FilterScanner) items(ctx context.Context) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		for item := range items(ctx, s.scanner) {
+			if s.filter.Match(item) && !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func (s *FilterScanner) walk(ctx context.Context, emit emitFn) {
+	walk(ctx, s.scanner, func(item FileItem) walkAction {
+		if !s.filter.Match(item) {
+			return walkContinue
+		}
+
+		return emit(item)
+	})
+}
+
+func (s *FilterScanner) traversal() (traversal, bool) {
+	return traversalOf(s.scanner)
+}
+
+func (s *FilterScanner) seek(ctx context.Context, pos position) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		for item := range s.scanner.(seeker).seek(ctx, pos) {
+			if s.filter.Match(item) && !yield(item) {
+				return
+			}
+		}
+	}
+}
+
 func NewFilterRegularFilesScanner(scanner Scanner) *FilterScanner {
 	return NewFilterScanner(scanner, RegularFilesFilter)
 }
@@ -159,3 +241,7 @@ func NewFilterDirectoriesScanner(scanner Scanner) *FilterScanner {
 func NewFilterScanner(scanner Scanner, filter Filter) *FilterScanner {
 	return &FilterScanner{scanner, filter}
 }
+
+func filterDirEventsFn(f FileItem) bool {
+	return f.Kind != KindEntry
+}
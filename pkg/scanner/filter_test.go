@@ -55,6 +55,18 @@ func (f *fakeFileInfo) PathName() string {
 	return ""
 }
 
+func (f *fakeFileInfo) Root() string {
+	return ""
+}
+
+func (f *fakeFileInfo) RelPath() string {
+	return f.name
+}
+
+func (f *fakeFileInfo) Depth() uint {
+	return 1
+}
+
 func TestNamedFilter(t *testing.T) {
 	t.Run("Test name", ScannerTest(func(t *testing.T) {
 		f := MakeNamedFilter(NegativeFilter, "dummy-name")
@@ -133,6 +145,20 @@ func TestOrFilter(t *testing.T) {
 	}))
 }
 
+func TestNotFilter(t *testing.T) {
+	t.Run("When filter is negative", ScannerTest(func(t *testing.T) {
+		Expect(NotFilter(NegativeFilter).Match(FileItem{})).To(BeTrue())
+	}))
+
+	t.Run("When filter is positive", ScannerTest(func(t *testing.T) {
+		Expect(NotFilter(PositiveFilter).Match(FileItem{})).To(BeFalse())
+	}))
+
+	t.Run("When filter is negated twice", ScannerTest(func(t *testing.T) {
+		Expect(NotFilter(NotFilter(PositiveFilter)).Match(FileItem{})).To(BeTrue())
+	}))
+}
+
 func TestRegularFilesFilter(t *testing.T) {
 	t.Run("When FileItem.FileInfo is nil", ScannerTest(func(t *testing.T) {
 		Expect(RegularFilesFilter.Match(FileItem{})).To(BeFalse())
@@ -214,6 +240,15 @@ func TestFilterRegularFilesScanner(t *testing.T) {
 	}))
 }
 
+func TestFilterScannerCancel(t *testing.T) {
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-cancel")
+		defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", 20)...)).Purge()
+
+		ExpectCancelToStopScan(NewFilterRegularFilesScanner(MustScanner(NewBasicScanner(WithDir(dir)))))
+	}))
+}
+
 func TestFilterDirectoriesScanner(t *testing.T) {
 	t.Run("When inner scanner returns an error", ScannerTest(func(t *testing.T) {
 		fileChan, err := NewFilterDirectoriesScanner(&FailingScanner{}).Scan(context.TODO())
@@ -0,0 +1,808 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"os/user"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nameTrueFilter  = "TrueFilter"
+	nameFindFilter  = "FindFilter"
+	nameEmptyFilter = "EmptyFilter"
+)
+
+var trueFilter = MakeNamedFilter(FilterFn(func(FileItem) bool { return true }), nameTrueFilter)
+
+// FindError is the error of the find expression, Column is 1-based.
+type FindError struct {
+	Column int
+	Err    error
+}
+
+func (e *FindError) Error() string {
+	return fmt.Sprintf("column %d: %v", e.Column, e.Err)
+}
+
+func (e *FindError) Unwrap() error {
+	return e.Err
+}
+
+// FindExpr is the compiled find(1) expression.
+type FindExpr struct {
+	// Filter matches the items find would print
+	Filter Filter
+	// Prune matches the directories -prune is evaluated for, it's nil without -prune, see WithPrune
+	Prune Filter
+	// MinDepth & MaxDepth are given by -mindepth & -maxdepth, 0 unless set, see WithMinDepth.
+	// -maxdepth 0 is compiled into the Filter & Prune matching nothing instead
+	MinDepth uint
+	MaxDepth uint
+}
+
+// ParseFind compiles the find(1) expression, quoted the way the shell does it, e.g.
+//
+//	-name '*.log' -a \( -size +1M -o -mtime -7 \) ! -path '*/tmp/*'
+//
+// The operators are ( ), !, -not, -a, -and, -o, -or and the juxtaposition meaning -and. The primaries
+// are -name, -iname, -path, -ipath, -wholename, -regex, -iregex, -type, -size, -empty, -perm, -user,
+// -group, -newer, -mtime, -atime, -ctime, -mmin, -amin, -cmin, -mindepth, -maxdepth, -true, -false,
+// -print & -prune. They work the find way, e.g. -size counts 512-byte blocks by default and rounds
+// up. -regex & -iregex are the exception, they take the RE2 syntax of regexp instead of the emacs
+// one of find, so the groups are ( ) and the alternative is |. The emacs \( \) \| \{ \} are rejected
+// rather than matched literally. The relative times are evaluated against the clock, time.Now when
+// nil. The errors are *FindError.
+func ParseFind(expr string, clock Clock) (*FindExpr, error) {
+	return ParseFindFS(expr, clock, nil)
+}
+
+// ParseFindFS is ParseFind for the scanner reading through fsys, see WithFS. -empty lists the
+// directories & -newer stats the file through it then, the OS is used when it's nil.
+func ParseFindFS(expr string, clock Clock, fsys fs.FS) (*FindExpr, error) {
+	tokens, err := tokenizeFind(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if clock == nil {
+		clock = time.Now
+	}
+
+	p := &findParser{tokens: tokens, end: len(expr) + 1, clock: clock, fsys: newFileSystem(fsys)}
+
+	if len(tokens) == 0 {
+		return &FindExpr{Filter: trueFilter}, nil
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.peek(); ok {
+		return nil, p.errorf(t.column, "unexpected %s", t.text)
+	}
+
+	f := &FindExpr{Filter: n.value, Prune: n.prune, MinDepth: p.minDepth, MaxDepth: p.maxDepth}
+
+	// -print prints only where it's evaluated, the whole expression is printed otherwise
+	if p.hasPrint {
+		f.Filter = n.print
+		if f.Filter == nil {
+			f.Filter = NotFilter(trueFilter)
+		}
+	}
+
+	// -maxdepth 0 keeps find at the roots, which are not the items, so nothing matches below them
+	if p.maxDepthSet && p.maxDepth == 0 {
+		f.Filter, f.Prune = NotFilter(trueFilter), trueFilter
+	}
+
+	return f, nil
+}
+
+type findToken struct {
+	text   string
+	column int
+}
+
+// tokenizeFind splits the expression into the arguments the shell would pass to find.
+func tokenizeFind(expr string) ([]findToken, error) {
+	var tokens []findToken
+
+	for i := 0; i < len(expr); {
+		if isFindSpace(expr[i]) {
+			i++
+			continue
+		}
+
+		var (
+			start = i
+			b     strings.Builder
+		)
+
+		for i < len(expr) && !isFindSpace(expr[i]) {
+			switch expr[i] {
+			case '\'':
+				j := strings.IndexByte(expr[i+1:], '\'')
+				if j < 0 {
+					return nil, &FindError{i + 1, errors.New("unterminated quote")}
+				}
+
+				b.WriteString(expr[i+1 : i+1+j])
+				i += j + 2
+			case '"':
+				j := i + 1
+				for ; j < len(expr) && expr[j] != '"'; j++ {
+					// within the double quotes the backslash escapes the special characters only
+					if expr[j] == '\\' && j+1 < len(expr) && strings.IndexByte("\"\\$`", expr[j+1]) >= 0 {
+						j++
+					}
+
+					b.WriteByte(expr[j])
+				}
+
+				if j == len(expr) {
+					return nil, &FindError{i + 1, errors.New("unterminated quote")}
+				}
+
+				i = j + 1
+			case '\\':
+				if i+1 == len(expr) {
+					return nil, &FindError{i + 1, errors.New("trailing backslash")}
+				}
+
+				b.WriteByte(expr[i+1])
+				i += 2
+			default:
+				b.WriteByte(expr[i])
+				i++
+			}
+		}
+
+		tokens = append(tokens, findToken{b.String(), start + 1})
+	}
+
+	return tokens, nil
+}
+
+func isFindSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// findNode is the compiled subexpression. The value tells whether it's true, prune & print whether
+// -prune & -print are evaluated then, the way find short-circuits. They are nil when never evaluated.
+type findNode struct {
+	value Filter
+	prune Filter
+	print Filter
+}
+
+type findParser struct {
+	tokens []findToken
+	pos    int
+	end    int
+	clock  Clock
+	fsys   fileSystem
+
+	hasPrint    bool
+	minDepth    uint
+	maxDepth    uint
+	maxDepthSet bool
+}
+
+func (p *findParser) peek() (findToken, bool) {
+	if p.pos == len(p.tokens) {
+		return findToken{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *findParser) next() (findToken, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+
+	return t, ok
+}
+
+func (p *findParser) errorf(column int, format string, args ...interface{}) error {
+	return &FindError{column, fmt.Errorf(format, args...)}
+}
+
+func (p *findParser) parseOr() (findNode, error) {
+	var operands []findNode
+
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return findNode{}, err
+		}
+
+		operands = append(operands, n)
+		if t, ok := p.peek(); !ok || (t.text != "-o" && t.text != "-or") {
+			break
+		}
+
+		p.pos++
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	// the next operand is evaluated only when none of the previous ones is true
+	var (
+		n     findNode
+		none  []Filter
+		value []Filter
+	)
+
+	for _, operand := range operands {
+		n.prune = orFindFilter(n.prune, andFindFilter(none, operand.prune))
+		n.print = orFindFilter(n.print, andFindFilter(none, operand.print))
+		value = append(value, operand.value)
+		none = append(none, NotFilter(operand.value))
+	}
+
+	n.value = OrFilter(value...)
+	return n, nil
+}
+
+func (p *findParser) parseAnd() (findNode, error) {
+	var operands []findNode
+
+	for {
+		n, err := p.parseNot()
+		if err != nil {
+			return findNode{}, err
+		}
+
+		operands = append(operands, n)
+
+		t, ok := p.peek()
+		if !ok || t.text == "-o" || t.text == "-or" || t.text == ")" {
+			break
+		}
+
+		if t.text == "-a" || t.text == "-and" {
+			p.pos++
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	// the next operand is evaluated only when all the previous ones are true
+	var (
+		n     findNode
+		value []Filter
+	)
+
+	for _, operand := range operands {
+		n.prune = orFindFilter(n.prune, andFindFilter(value, operand.prune))
+		n.print = orFindFilter(n.print, andFindFilter(value, operand.print))
+		value = append(value, operand.value)
+	}
+
+	n.value = AndFilter(value...)
+	return n, nil
+}
+
+func (p *findParser) parseNot() (findNode, error) {
+	t, ok := p.next()
+	if !ok {
+		return findNode{}, p.errorf(p.end, "expected expression")
+	}
+
+	switch t.text {
+	case "!", "-not":
+		n, err := p.parseNot()
+		if err != nil {
+			return findNode{}, err
+		}
+
+		n.value = NotFilter(n.value)
+		return n, nil
+	case "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return findNode{}, err
+		}
+
+		if closing, ok := p.next(); !ok || closing.text != ")" {
+			return findNode{}, p.errorf(t.column, "unclosed (")
+		}
+
+		return n, nil
+	case ")", "-o", "-or", "-a", "-and":
+		return findNode{}, p.errorf(t.column, "expected expression before %s", t.text)
+	}
+
+	return p.parsePrimary(t)
+}
+
+func (p *findParser) parsePrimary(t findToken) (findNode, error) {
+	switch t.text {
+	case "-true":
+		return findNode{value: trueFilter}, nil
+	case "-false":
+		return findNode{value: NotFilter(trueFilter)}, nil
+	case "-prune":
+		return findNode{value: trueFilter, prune: trueFilter}, nil
+	case "-print":
+		p.hasPrint = true
+		return findNode{value: trueFilter, print: trueFilter}, nil
+	case "-empty":
+		return findNode{value: findEmptyFilter(p.fsys)}, nil
+	}
+
+	if !findArgPrimaries[t.text] {
+		return findNode{}, p.errorf(t.column, "unknown primary %s", t.text)
+	}
+
+	arg, ok := p.next()
+	if !ok {
+		return findNode{}, p.errorf(t.column, "%s needs an argument", t.text)
+	}
+
+	filter, err := p.primaryFilter(t.text, arg.text)
+	if err != nil {
+		return findNode{}, &FindError{arg.column, err}
+	}
+
+	return findNode{value: filter}, nil
+}
+
+// findArgPrimaries are the primaries taking an argument, see primaryFilter.
+var findArgPrimaries = map[string]bool{
+	"-name": true, "-iname": true, "-path": true, "-ipath": true, "-wholename": true,
+	"-regex": true, "-iregex": true, "-type": true, "-size": true, "-perm": true,
+	"-user": true, "-group": true, "-newer": true,
+	"-mtime": true, "-atime": true, "-ctime": true, "-mmin": true, "-amin": true, "-cmin": true,
+	"-mindepth": true, "-maxdepth": true,
+}
+
+func (p *findParser) primaryFilter(primary, arg string) (Filter, error) {
+	switch primary {
+	case "-name", "-iname":
+		return findNameFilter(arg, primary == "-iname")
+	case "-path", "-ipath", "-wholename":
+		r, err := findPathRegexp(arg, primary == "-ipath")
+		if err != nil {
+			return nil, err
+		}
+
+		return findPathFilter(r), nil
+	case "-regex", "-iregex":
+		flags := ""
+		if primary == "-iregex" {
+			flags = "(?i)"
+		}
+
+		if err := checkFindRegexp(arg); err != nil {
+			return nil, err
+		}
+
+		// the whole path has to match
+		r, err := regexp.Compile(flags + "^(?:" + arg + ")$")
+		if err != nil {
+			return nil, err
+		}
+
+		return findPathFilter(r), nil
+	case "-type":
+		return findTypeFilter(arg)
+	case "-size":
+		return findSizeFilter(arg)
+	case "-perm":
+		return findPermFilter(arg)
+	case "-user", "-group":
+		return findOwnerFilter(arg, primary == "-group")
+	case "-newer":
+		return timeNewerThanFileFilter(p.fsys, TimeModified, arg)
+	case "-mtime", "-atime", "-ctime":
+		return p.findTimeFilter(primary, arg, 24*time.Hour)
+	case "-mmin", "-amin", "-cmin":
+		return p.findTimeFilter(primary, arg, time.Minute)
+	case "-mindepth", "-maxdepth":
+		depth, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid depth %q", arg)
+		}
+
+		if primary == "-mindepth" {
+			p.minDepth = uint(depth)
+		} else {
+			p.maxDepth, p.maxDepthSet = uint(depth), true
+		}
+
+		// depth is global, it's true wherever it is placed
+		return trueFilter, nil
+	}
+
+	return nil, fmt.Errorf("unknown primary %s", primary)
+}
+
+// checkFindRegexp rejects the emacs operators, which RE2 would take for the literal characters.
+func checkFindRegexp(expr string) error {
+	for i := 0; i < len(expr)-1; i++ {
+		if expr[i] != '\\' {
+			continue
+		}
+
+		if strings.IndexByte("()|{}", expr[i+1]) >= 0 {
+			return fmt.Errorf("emacs regex operator \\%c is not supported, use RE2 syntax", expr[i+1])
+		}
+
+		// escaped character is never the operator
+		i++
+	}
+
+	return nil
+}
+
+// findNameFilter matches the name, *.ext is the ExtensionFilter.
+func findNameFilter(pattern string, foldCase bool) (Filter, error) {
+	if ext := strings.TrimPrefix(pattern, "*"); !foldCase && ext != pattern && !strings.ContainsAny(ext, "*?[\\") {
+		return ExtensionFilter(ext), nil
+	}
+
+	if foldCase {
+		pattern = strings.ToLower(pattern)
+	}
+
+	pattern = strings.ReplaceAll(pattern, "[!", "[^")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		name := file.FileInfo.Name()
+		if foldCase {
+			name = strings.ToLower(name)
+		}
+
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}), nameFindFilter), nil
+}
+
+// findPathRegexp translates the pattern of -path, which wildcards match the slashes as well.
+func findPathRegexp(pattern string, foldCase bool) (*regexp.Regexp, error) {
+	var (
+		b     strings.Builder
+		runes = []rune(pattern)
+	)
+
+	b.WriteString("(?s)")
+	if foldCase {
+		b.WriteString("(?i)")
+	}
+
+	b.WriteString("^")
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i++; i == len(runes) {
+				return nil, path.ErrBadPattern
+			}
+
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '[':
+			j := i + 1
+			if j < len(runes) && (runes[j] == '!' || runes[j] == '^') {
+				j++
+			}
+
+			// ] right after the opening one is the literal
+			if j < len(runes) && runes[j] == ']' {
+				j++
+			}
+
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+
+			if j == len(runes) {
+				return nil, path.ErrBadPattern
+			}
+
+			class := runes[i+1 : j]
+			b.WriteString("[")
+			if len(class) > 0 && (class[0] == '!' || class[0] == '^') {
+				b.WriteString("^")
+				class = class[1:]
+			}
+
+			for _, c := range class {
+				if c == '\\' || c == '[' || c == ']' {
+					b.WriteRune('\\')
+				}
+
+				b.WriteRune(c)
+			}
+
+			b.WriteString("]")
+			i = j
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func findPathFilter(r *regexp.Regexp) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		return r.MatchString(file.FileInfo.PathName())
+	}), nameFindFilter)
+}
+
+var findTypes = map[byte]Filter{
+	'f': RegularFilesFilter,
+	'd': DirectoriesFilter,
+	'l': findModeFilter(os.ModeSymlink),
+	'p': findModeFilter(os.ModeNamedPipe),
+	's': findModeFilter(os.ModeSocket),
+	'c': findModeFilter(os.ModeDevice | os.ModeCharDevice),
+	'b': findModeFilter(os.ModeDevice),
+}
+
+// findTypeFilter matches any of the comma separated types, e.g. f,d.
+func findTypeFilter(arg string) (Filter, error) {
+	var filters []Filter
+	for _, t := range strings.Split(arg, ",") {
+		if len(t) != 1 || findTypes[t[0]] == nil {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+
+		filters = append(filters, findTypes[t[0]])
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return OrFilter(filters...), nil
+}
+
+func findModeFilter(mode os.FileMode) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		return fileType(file.FileInfo)&os.ModeType == mode
+	}), nameFindFilter)
+}
+
+var findSizeUnits = map[byte]int64{
+	'c': 1,
+	'w': 2,
+	'b': 512,
+	'k': KiB,
+	'M': MiB,
+	'G': GiB,
+}
+
+// findSizeFilter compares the size in the units rounded up, so -size -1M matches the empty files only.
+func findSizeFilter(arg string) (Filter, error) {
+	if arg == "" {
+		return nil, ErrInvalidSize
+	}
+
+	unit := int64(512)
+	if u, ok := findSizeUnits[arg[len(arg)-1]]; ok && len(arg) > 1 {
+		unit, arg = u, arg[:len(arg)-1]
+	}
+
+	cmp, n, err := parseFindNumber(arg)
+	if err != nil || n > math.MaxInt64/unit {
+		return nil, ErrInvalidSize
+	}
+
+	switch {
+	case cmp > 0:
+		return SizeLargerThanFilter(n*unit, SizeApparent), nil
+	case cmp < 0:
+		return SizeSmallerThanFilter((n-1)*unit+1, SizeApparent), nil
+	case n == 0:
+		return SizeBetweenFilter(0, 0, SizeApparent), nil
+	}
+
+	return SizeBetweenFilter((n-1)*unit+1, n*unit, SizeApparent), nil
+}
+
+// findTimeFilter compares the age in the units rounded down, the way find does it.
+func (p *findParser) findTimeFilter(primary, arg string, unit time.Duration) (Filter, error) {
+	cmp, n, err := parseFindNumber(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	field := map[byte]TimeField{'m': TimeModified, 'a': TimeAccessed, 'c': TimeChanged}[primary[1]]
+
+	clock := p.clock
+	return timeFilter(field, func(ft time.Time) bool {
+		age := int64(clock().Sub(ft) / unit)
+		switch {
+		case cmp > 0:
+			return age > n
+		case cmp < 0:
+			return age < n
+		}
+
+		return age == n
+	}), nil
+}
+
+// parseFindNumber parses the number with the optional + (more than) or - (less than) sign.
+func parseFindNumber(arg string) (int, int64, error) {
+	cmp := 0
+	switch {
+	case strings.HasPrefix(arg, "+"):
+		cmp, arg = 1, arg[1:]
+	case strings.HasPrefix(arg, "-"):
+		cmp, arg = -1, arg[1:]
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, 0, fmt.Errorf("invalid number %q", arg)
+	}
+
+	return cmp, n, nil
+}
+
+// findPermFilter matches the octal mode exactly, -mode all of its bits, /mode any of them.
+func findPermFilter(arg string) (Filter, error) {
+	match := func(mode, perm uint32) bool { return mode == perm }
+	switch {
+	case strings.HasPrefix(arg, "-"):
+		arg, match = arg[1:], func(mode, perm uint32) bool { return mode&perm == perm }
+	case strings.HasPrefix(arg, "/"):
+		arg, match = arg[1:], func(mode, perm uint32) bool { return perm == 0 || mode&perm != 0 }
+	}
+
+	perm, err := strconv.ParseUint(arg, 8, 32)
+	if err != nil || perm > 07777 {
+		return nil, fmt.Errorf("invalid mode %q, only the octal ones are supported", arg)
+	}
+
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		return match(unixMode(file.FileInfo.Mode()), uint32(perm))
+	}), nameFindFilter), nil
+}
+
+// unixMode is the permission bits of the mode, including setuid, setgid & sticky.
+func unixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+
+	return bits
+}
+
+// findOwnerFilter matches the user, or the group, given by the name or the numeric ID.
+func findOwnerFilter(arg string, group bool) (Filter, error) {
+	id, err := strconv.ParseUint(arg, 10, 32)
+	if err != nil {
+		var name string
+		if group {
+			g, lookupErr := user.LookupGroup(arg)
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+
+			name = g.Gid
+		} else {
+			u, lookupErr := user.Lookup(arg)
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+
+			name = u.Uid
+		}
+
+		if id, err = strconv.ParseUint(name, 10, 32); err != nil {
+			return nil, err
+		}
+	}
+
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		uid, gid, ok := fileOwner(file.FileInfo)
+		if group {
+			return ok && gid == uint32(id)
+		}
+
+		return ok && uid == uint32(id)
+	}), nameFindFilter), nil
+}
+
+// findEmptyFilter matches the empty regular files & the empty directories, listed through fsys.
+func findEmptyFilter(fsys fileSystem) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		switch t := fileType(file.FileInfo); {
+		case t.IsRegular():
+			return file.FileInfo.Size() == 0
+		case t.IsDir():
+			d, err := fsys.OpenDir(file.FileInfo.PathName())
+			if err != nil {
+				return false
+			}
+
+			defer d.Close()
+
+			_, err = d.ReadDir(1)
+			return err == io.EOF
+		}
+
+		return false
+	}), nameEmptyFilter)
+}
+
+func andFindFilter(filters []Filter, filter Filter) Filter {
+	if filter == nil || len(filters) == 0 {
+		return filter
+	}
+
+	return AndFilter(append(filters[:len(filters):len(filters)], filter)...)
+}
+
+func orFindFilter(a, b Filter) Filter {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+
+	return OrFilter(a, b)
+}
@@ -0,0 +1,234 @@
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestParseFindErrors(t *testing.T) {
+	for _, tc := range []struct {
+		expr   string
+		column int
+	}{
+		{"-name", 1},
+		{"-name '*.log' -foo", 15},
+		{"-name x foo", 9},
+		{"\\( -name x", 1},
+		{"-name x \\)", 9},
+		{"-name 'abc", 7},
+		{"-name \"abc", 7},
+		{"-o -name x", 1},
+		{"-name x -o", 11},
+		{"!", 2},
+		{"-type q", 7},
+		{"-type f,", 7},
+		{"-perm u+x", 7},
+		{"-mtime x", 8},
+		{"-maxdepth x", 11},
+		{"-regex '('", 8},
+		{`-regex '.*\(a\|b\)'`, 8},
+	} {
+		t.Run("When "+tc.expr+" is parsed", ScannerTest(func(t *testing.T) {
+			_, err := ParseFind(tc.expr, nil)
+
+			var findErr *FindError
+			Expect(errors.As(err, &findErr)).To(BeTrue())
+			Expect(findErr.Column).To(Equal(tc.column))
+			Expect(err.Error()).To(HavePrefix("column " + strconv.Itoa(tc.column) + ": "))
+		}))
+	}
+
+	t.Run("When argument is invalid", ScannerTest(func(t *testing.T) {
+		_, err := ParseFind("-size +1X", nil)
+		Expect(errors.Is(err, ErrInvalidSize)).To(BeTrue())
+
+		_, err = ParseFind("-name '[a-'", nil)
+		Expect(errors.Is(err, path.ErrBadPattern)).To(BeTrue())
+	}))
+}
+
+func TestParseFind(t *testing.T) {
+	var (
+		now   = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+		clock = func() time.Time { return now }
+		big   = []byte(strings.Repeat("x", int(MiB)+1))
+		fsys  = fstest.MapFS{
+			"app.log":                    {Data: big},
+			"readme.md":                  {ModTime: now.Add(-time.Hour)},
+			"empty.txt":                  {ModTime: now.AddDate(0, 0, -3)},
+			"logs/recent.log":            {Data: []byte("x"), ModTime: now.AddDate(0, 0, -1)},
+			"logs/old.log":               {Data: []byte("x"), ModTime: now.AddDate(0, 0, -30)},
+			"logs/big.log":               {Data: big, ModTime: now.AddDate(0, 0, -30)},
+			"logs/1k.LOG":                {Data: []byte(strings.Repeat("x", int(KiB)))},
+			"logs/tmp/recent.log":        {Data: []byte("x"), ModTime: now.AddDate(0, 0, -1)},
+			"src/main.js":                {},
+			"src/node_modules/dep/a.js":  {},
+			"src/node_modules/README.md": {},
+		}
+	)
+
+	find := func(expr string) []string {
+		f, err := ParseFindFS(expr, clock, fsys)
+		Expect(err).ToNot(HaveOccurred())
+
+		options := []RecursiveScannerOptionFn{WithDirectories("."), WithScannerOptions(WithFS(fsys))}
+		if f.Prune != nil {
+			options = append(options, WithPrune(f.Prune))
+		}
+
+		s := NewFilterScanner(MustScanner(NewRecursiveScanner(options...)), f.Filter)
+		return SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))
+	}
+
+	for _, tc := range []struct {
+		expr      string
+		pathNames []string
+	}{
+		{
+			`-name '*.log' -a \( -size +1M -o -mtime -7 \) ! -path '*/tmp/*'`,
+			[]string{"app.log", "logs/big.log", "logs/recent.log"},
+		},
+		{`-iname "*.log" -size 2`, []string{"logs/1k.LOG"}},
+		{`-name '*.[lL][oO][gG]' -size 1k`, []string{"logs/1k.LOG", "logs/old.log", "logs/recent.log", "logs/tmp/recent.log"}},
+		{`-size -1M -type f -path 'src/*'`, []string{"src/main.js", "src/node_modules/README.md", "src/node_modules/dep/a.js"}},
+		{`-type d`, []string{"logs", "logs/tmp", "src", "src/node_modules", "src/node_modules/dep"}},
+		{`-type d,f -name 'READ*'`, []string{"src/node_modules/README.md"}},
+		{`-ipath 'LOGS/*/*' -o -regex '.*\.md'`, []string{"logs/tmp/recent.log", "readme.md", "src/node_modules/README.md"}},
+		{`-mtime 0 -type f`, []string{"readme.md"}},
+		{`-mtime +3 -name '*.log'`, []string{"app.log", "logs/big.log", "logs/old.log"}},
+		{`-mmin -90 -not -name '*.log'`, []string{"readme.md"}},
+		{`-name node_modules -prune -o -name '*.js'`, []string{"src/main.js", "src/node_modules"}},
+		{`-name node_modules -prune -o -name '*.js' -print`, []string{"src/main.js"}},
+		{`-path src -prune -o -false`, []string{"src"}},
+		{`-name '*.md' ! -path 'src/*' -o -name '*.js' -a -true`, []string{"readme.md", "src/main.js", "src/node_modules/dep/a.js"}},
+		{`-regex '.*/(recent|big)\.log'`, []string{"logs/big.log", "logs/recent.log", "logs/tmp/recent.log"}},
+		{`-newer empty.txt`, []string{"logs/recent.log", "logs/tmp/recent.log", "readme.md"}},
+		{`-mindepth 0 -name '*.md'`, []string{"readme.md", "src/node_modules/README.md"}},
+		{`-maxdepth 0`, nil},
+		{`-empty -type f -path 'src/*'`, []string{"src/main.js", "src/node_modules/README.md", "src/node_modules/dep/a.js"}},
+	} {
+		t.Run("When "+tc.expr+" is matched", ScannerTest(func(t *testing.T) {
+			Expect(find(tc.expr)).To(Equal(tc.pathNames))
+		}))
+	}
+
+	t.Run("When expression is empty", ScannerTest(func(t *testing.T) {
+		Expect(find("  ")).To(HaveLen(16))
+	}))
+
+	t.Run("When directories are empty", ScannerTest(func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"empty":      {Mode: fs.ModeDir | 0755},
+			"full/a.txt": {Data: []byte("x")},
+		}
+
+		fileChan, err := NewBuilder().Recursive().FS(fsys).In(".").Find("-empty").MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(FileChanToPathNames(fileChan)).To(Equal([]string{"empty"}))
+	}))
+
+	t.Run("When builder is given the expression", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewBuilder().Recursive().FS(fsys).In(".").Clock(clock).
+			Find(`-name node_modules -prune -o -maxdepth 2 -mindepth 2 -mtime -2`).
+			MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{"logs/recent.log", "src/node_modules"}))
+
+		_, err = NewBuilder().Recursive().In(".").Find("-name").Build()
+
+		var findErr *FindError
+		Expect(errors.As(err, &findErr)).To(BeTrue())
+	}))
+}
+
+func TestParseFindOS(t *testing.T) {
+	dir := t.TempDir()
+	for name, perm := range map[string]os.FileMode{"private.txt": 0600, "script.sh": 0755, "shared.txt": 0664} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), perm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chmod(filepath.Join(dir, name), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, d := range []string{"empty", "full"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "full", "empty.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("script.sh", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(expr string) []string {
+		f, err := ParseFind(expr, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		s := NewFilterScanner(MustScanner(NewRecursiveScanner(WithDirectories(dir))), f.Filter)
+
+		var names []string
+		for _, pathName := range FileChanToPathNames(MustScan(s.Scan(context.TODO()))) {
+			name, _ := filepath.Rel(dir, pathName)
+			names = append(names, name)
+		}
+
+		return SortedPathNames(names)
+	}
+
+	t.Run("When files are empty", ScannerTest(func(t *testing.T) {
+		Expect(find("-empty")).To(Equal([]string{"empty", "full/empty.txt"}))
+	}))
+
+	t.Run("When files are symlinks", ScannerTest(func(t *testing.T) {
+		Expect(find("-type l")).To(Equal([]string{"link"}))
+	}))
+
+	t.Run("When permissions are compared", ScannerTest(func(t *testing.T) {
+		Expect(find("-perm 600")).To(Equal([]string{"private.txt"}))
+		Expect(find("-type f -perm -444")).To(Equal([]string{"full/empty.txt", "script.sh", "shared.txt"}))
+		Expect(find("-type f -perm /111")).To(Equal([]string{"script.sh"}))
+		Expect(find("-type f -perm /020")).To(Equal([]string{"shared.txt"}))
+	}))
+
+	t.Run("When owner is compared", ScannerTest(func(t *testing.T) {
+		Expect(find("-user " + strconv.Itoa(os.Getuid()) + " -name '*.sh'")).To(Equal([]string{"script.sh"}))
+		Expect(find("-group " + strconv.Itoa(os.Getgid()) + " -name '*.sh'")).To(Equal([]string{"script.sh"}))
+		Expect(find("-user " + strconv.Itoa(os.Getuid()+1))).To(BeEmpty())
+
+		_, err := ParseFind("-user no-such-user-for-sure", nil)
+
+		var findErr *FindError
+		Expect(errors.As(err, &findErr)).To(BeTrue())
+		Expect(findErr.Column).To(Equal(7))
+	}))
+
+	t.Run("When files are newer than the reference", ScannerTest(func(t *testing.T) {
+		reference := filepath.Join(dir, "full", "empty.txt")
+		past := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(filepath.Join(dir, "private.txt"), past, past)).To(Succeed())
+		Expect(os.Chtimes(reference, past.Add(time.Minute), past.Add(time.Minute))).To(Succeed())
+
+		Expect(find("-newer " + reference + " -name '*.txt'")).To(Equal([]string{"shared.txt"}))
+	}))
+}
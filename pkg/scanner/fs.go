@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// fileSystem is what the scanners read the directories through, the OS unless WithFS is given.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	OpenDir(name string) (dirFile, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+// dirFile is the opened directory, read in bulks the same way os.File is.
+type dirFile interface {
+	Readdir(n int) ([]os.FileInfo, error)
+	ReadDir(n int) ([]fs.DirEntry, error)
+	Stat() (os.FileInfo, error)
+	Close() error
+}
+
+// newFileSystem reads through fsys, the OS when it's nil.
+func newFileSystem(fsys fs.FS) fileSystem {
+	if fsys == nil {
+		return osFileSystem{}
+	}
+
+	return ioFileSystem{fsys}
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFileSystem) OpenDir(name string) (dirFile, error) {
+	return os.Open(name)
+}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+type ioFileSystem struct {
+	fsys fs.FS
+}
+
+func (f ioFileSystem) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.fsys, name)
+}
+
+func (f ioFileSystem) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, name)
+}
+
+func (f ioFileSystem) OpenDir(name string) (dirFile, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if rd, ok := file.(fs.ReadDirFile); ok {
+		return &fsDir{File: file, readDir: rd.ReadDir}, nil
+	}
+
+	// directory can't be read in bulks, but the filesystem may list it at once
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fsDir{File: file, readDir: readListed(entries)}, nil
+}
+
+type fsDir struct {
+	fs.File
+	readDir func(n int) ([]fs.DirEntry, error)
+}
+
+// readListed reads the listing in bulks, following the fs.ReadDirFile contract.
+func readListed(entries []fs.DirEntry) func(n int) ([]fs.DirEntry, error) {
+	return func(n int) ([]fs.DirEntry, error) {
+		if n <= 0 {
+			bulk := entries
+			entries = nil
+			return bulk, nil
+		}
+
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+
+		bulk := entries[:min(n, len(entries))]
+		entries = entries[len(bulk):]
+
+		return bulk, nil
+	}
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	return d.readDir(n)
+}
+
+func (d *fsDir) Readdir(n int) ([]os.FileInfo, error) {
+	entries, err := d.readDir(n)
+
+	infos, infoErr := entryInfos(entries)
+	if infoErr != nil {
+		return infos, infoErr
+	}
+
+	return infos, err
+}
+
+// entryInfos stats the entries, up to the first error.
+func entryInfos(entries []fs.DirEntry) ([]os.FileInfo, error) {
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, infoErr := entry.Info()
+		if errors.Is(infoErr, fs.ErrNotExist) {
+			// removed in the meantime, the same way os.File skips it
+			continue
+		}
+
+		if infoErr != nil {
+			return infos, infoErr
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// statDirEntry stats the entry with lstat instead of its own Info.
+type statDirEntry struct {
+	fs.DirEntry
+	lstat func(name string) (os.FileInfo, error)
+}
+
+func (e statDirEntry) Info() (fs.FileInfo, error) {
+	return e.lstat(e.Name())
+}
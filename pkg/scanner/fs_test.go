@@ -0,0 +1,131 @@
+package scanner_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// ListingFS lists the directories at once, its files can't be read in bulks.
+type ListingFS struct {
+	fstest.MapFS
+}
+
+func (fsys ListingFS) Open(name string) (fs.File, error) {
+	f, err := fsys.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct{ fs.File }{f}, nil
+}
+
+func (fsys ListingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fsys.MapFS.ReadDir(name)
+}
+
+func TestWithFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"level-0-file-1.jpg":                                 {Data: []byte("jpg")},
+		"level-0-directory-1/level-1-file-1.1.jpg":           {},
+		"level-0-directory-1/level-1-directory-1.2/file.jpg": {},
+		"level-0-directory-2/level-1-file-2.1.jpg":           {},
+		"level-0-directory-3":                                {Mode: fs.ModeDir},
+	}
+
+	expected := []string{
+		"level-0-directory-1",
+		"level-0-directory-1/level-1-directory-1.2",
+		"level-0-directory-1/level-1-directory-1.2/file.jpg",
+		"level-0-directory-1/level-1-file-1.1.jpg",
+		"level-0-directory-2",
+		"level-0-directory-2/level-1-file-2.1.jpg",
+		"level-0-directory-3",
+		"level-0-file-1.jpg",
+	}
+
+	t.Run("When BasicScanner reads the filesystem", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewBasicScanner(WithDir("level-0-directory-1"), WithFS(fsys), WithOrder(OrderLexical), WithBulkSize(1)))
+
+		files := FileChanToSlice(MustScan(s.Scan(context.TODO())))
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].FileInfo.PathName()).To(Equal("level-0-directory-1/level-1-directory-1.2"))
+		Expect(files[0].FileInfo.IsDir()).To(BeTrue())
+		Expect(files[1].FileInfo.PathName()).To(Equal("level-0-directory-1/level-1-file-1.1.jpg"))
+		Expect(files[1].FileInfo.RelPath()).To(Equal("level-1-file-1.1.jpg"))
+	}))
+
+	t.Run("When BasicScanner reads the root of the filesystem", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewBasicScanner(WithFS(fsys), WithDir("."), WithDirEvents()))
+
+		files := FileChanToSlice(MustScan(s.Scan(context.TODO())))
+		Expect(files).To(HaveLen(6))
+		Expect(files[0].Kind).To(Equal(KindDirStart))
+		Expect(files[0].FileInfo.PathName()).To(Equal("."))
+		Expect(files).To(HaveRegularFiles(1))
+		Expect(files).To(HaveDirectories(5))
+	}))
+
+	t.Run("When RecursiveScanner reads the filesystem", ScannerTest(func(t *testing.T) {
+		for _, strategy := range []Strategy{StrategyParallel, StrategyDepthFirst, StrategyBreadthFirst} {
+			s := MustScanner(NewRecursiveScanner(WithDirectories("."), WithStrategy(strategy), WithScannerOptions(WithFS(fsys))))
+
+			Expect(SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))).To(Equal(expected))
+			Expect(SortedPathNames(SeqToPathNames(All(context.TODO(), s)))).To(Equal(expected))
+		}
+	}))
+
+	t.Run("When filesystem lists the directories at once", ScannerTest(func(t *testing.T) {
+		s := MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(ListingFS{fsys}), WithBulkSize(2))))
+
+		Expect(SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))).To(Equal(expected))
+	}))
+
+	t.Run("When directory does not exist in the filesystem", ScannerTest(func(t *testing.T) {
+		_, err := NewBasicScanner(WithDir("level-0-directory-4"), WithFS(fsys))
+		Expect(err).To(HaveOccurred())
+
+		_, err = NewRecursiveScanner(WithDirectories("level-0-directory-4"), WithScannerOptions(WithFS(fsys)))
+		Expect(err).To(HaveOccurred())
+
+		// the directory exists in the OS, but not in the filesystem
+		_, err = NewRecursiveScanner(WithDirectories(os.TempDir()), WithScannerOptions(WithFS(fsys)))
+		Expect(err).To(HaveOccurred())
+	}))
+
+	t.Run("When directory is not a dir", ScannerTest(func(t *testing.T) {
+		_, err := NewBasicScanner(WithDir("level-0-file-1.jpg"), WithFS(fsys))
+		Expect(err).To(Equal(ErrNotDirectory))
+
+		_, err = NewRecursiveScanner(WithDirectories("level-0-file-1.jpg"), WithScannerOptions(WithFS(fsys)))
+		Expect(err).To(Equal(ErrNotDirectory))
+	}))
+
+	t.Run("When the OS directory is read through os.DirFS", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-read-through-fs")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceFile("level-0-file-1.jpg"),
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceFile("level-1-file-1.1.jpg"),
+			),
+		)).Purge()
+
+		var relPaths []string
+		s := MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(os.DirFS(dir)))))
+		for info, err := range All(context.TODO(), s) {
+			Expect(err).ToNot(HaveOccurred())
+			relPaths = append(relPaths, info.RelPath())
+		}
+
+		Expect(SortedPathNames(relPaths)).To(Equal([]string{
+			"level-0-directory-1",
+			"level-0-directory-1/level-1-file-1.1.jpg",
+			"level-0-file-1.jpg",
+		}))
+	}))
+}
@@ -0,0 +1,222 @@
+//go:build linux
+
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// getdentsBufferSize fits a few hundred entries of a common name length.
+const getdentsBufferSize = 32 << 10
+
+var getdentsBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, getdentsBufferSize)
+		return &buf
+	},
+}
+
+// offsets within the linux_dirent64 record
+const (
+	direntInoOffset    = 0
+	direntReclenOffset = 16
+	direntTypeOffset   = 18
+	direntNameOffset   = 19
+)
+
+// getdentsReader reads the directory straight with the getdents64 syscall into the reusable buffer.
+type getdentsReader struct {
+	f     *os.File
+	lstat func(name string) (os.FileInfo, error)
+	buf   *[]byte
+}
+
+// newGetdentsReader returns false when the directory is not read from the OS. The entries are
+// stat-ed with lstat.
+func newGetdentsReader(d dirFile, lstat func(name string) (os.FileInfo, error)) (bulkReader, bool) {
+	f, ok := d.(*os.File)
+	if !ok {
+		return nil, false
+	}
+
+	return &getdentsReader{f: f, lstat: lstat, buf: getdentsBuffers.Get().(*[]byte)}, true
+}
+
+func (r *getdentsReader) readBulk() ([]os.FileInfo, error) {
+	for {
+		n, err := r.getdents()
+		if err != nil {
+			return nil, os.NewSyscallError("getdents64", err)
+		}
+
+		if n <= 0 {
+			return nil, io.EOF
+		}
+
+		// the buffer may hold the "." & ".." entries only, read on then
+		if infos := r.parse((*r.buf)[:n]); len(infos) > 0 {
+			return infos, nil
+		}
+	}
+}
+
+func (r *getdentsReader) getdents() (int, error) {
+	rawConn, err := r.f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for {
+		controlErr := rawConn.Control(func(fd uintptr) {
+			n, err = syscall.Getdents(int(fd), *r.buf)
+		})
+
+		if controlErr != nil {
+			return 0, controlErr
+		}
+
+		if err != syscall.EINTR {
+			return n, err
+		}
+	}
+}
+
+// parse turns the records into the lazy-stat infos. The names, the entries and the infos are
+// allocated once per buffer rather than once per entry.
+func (r *getdentsReader) parse(buf []byte) []os.FileInfo {
+	var count, namesLen int
+	forEachDirent(buf, func(name []byte, _ uint8) {
+		count++
+		namesLen += len(name)
+	})
+
+	names := make([]byte, 0, namesLen)
+	entries := make([]direntEntry, 0, count)
+	forEachDirent(buf, func(name []byte, typ uint8) {
+		names = append(names, name...)
+		entries = append(entries, direntEntry{lstat: r.lstat, nameEnd: len(names), typ: direntType(typ)})
+	})
+
+	// the names are never written again, so the entries share them as a single string
+	var all string
+	if len(names) > 0 {
+		all = unsafe.String(&names[0], len(names))
+	}
+
+	infos := make([]os.FileInfo, 0, count)
+	lazyInfos := make([]lazyFileInfo, count)
+
+	var nameStart int
+	for i := range entries {
+		entry := &entries[i]
+		entry.name, nameStart = all[nameStart:entry.nameEnd], entry.nameEnd
+
+		if entry.typ == direntUnknown {
+			// the filesystem does not fill d_type, the stat can't be avoided
+			info, err := r.lstat(entry.name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			if err == nil {
+				entry.typ = info.Mode().Type()
+				lazyInfos[i].once.Do(func() { lazyInfos[i].info = info })
+			}
+		}
+
+		lazyInfos[i].entry = entry
+		infos = append(infos, &lazyInfos[i])
+	}
+
+	return infos
+}
+
+func (r *getdentsReader) release() {
+	getdentsBuffers.Put(r.buf)
+	r.buf = nil
+}
+
+// forEachDirent calls fn for every linux_dirent64 record but "." & ".." and the deleted ones.
+func forEachDirent(buf []byte, fn func(name []byte, typ uint8)) {
+	for len(buf) > direntNameOffset {
+		reclen := int(binary.NativeEndian.Uint16(buf[direntReclenOffset:]))
+		if reclen <= direntNameOffset || reclen > len(buf) {
+			return
+		}
+
+		record := buf[:reclen]
+		buf = buf[reclen:]
+
+		if binary.NativeEndian.Uint64(record[direntInoOffset:]) == 0 {
+			continue
+		}
+
+		name := record[direntNameOffset:]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+
+		if string(name) == "." || string(name) == ".." {
+			continue
+		}
+
+		fn(name, record[direntTypeOffset])
+	}
+}
+
+// direntUnknown marks the entry whose type has to be stat-ed, no other type uses the irregular bit.
+const direntUnknown = fs.ModeIrregular
+
+func direntType(typ uint8) fs.FileMode {
+	switch typ {
+	case syscall.DT_REG:
+		return 0
+	case syscall.DT_DIR:
+		return fs.ModeDir
+	case syscall.DT_LNK:
+		return fs.ModeSymlink
+	case syscall.DT_FIFO:
+		return fs.ModeNamedPipe
+	case syscall.DT_SOCK:
+		return fs.ModeSocket
+	case syscall.DT_CHR:
+		return fs.ModeDevice | fs.ModeCharDevice
+	case syscall.DT_BLK:
+		return fs.ModeDevice
+	}
+
+	return direntUnknown
+}
+
+// direntEntry is the directory entry as getdents64 returns it, the stat is left to Info.
+type direntEntry struct {
+	lstat   func(name string) (os.FileInfo, error)
+	name    string
+	nameEnd int
+	typ     fs.FileMode
+}
+
+func (e *direntEntry) Name() string {
+	return e.name
+}
+
+func (e *direntEntry) IsDir() bool {
+	return e.typ.IsDir()
+}
+
+func (e *direntEntry) Type() fs.FileMode {
+	return e.typ
+}
+
+func (e *direntEntry) Info() (fs.FileInfo, error) {
+	return e.lstat(e.name)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package scanner
+
+import "os"
+
+// newGetdentsReader is never available, the directories are read with ReadDir instead.
+func newGetdentsReader(d dirFile, lstat func(name string) (os.FileInfo, error)) (bulkReader, bool) {
+	return nil, false
+}
@@ -0,0 +1,83 @@
+package scanner_test
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestWithGetdents(t *testing.T) {
+	t.Run("When scanning the OS", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-getdents")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceFile("level-0-file-1.jpg"),
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceFile("level-1-file-1.1.jpg"),
+				NewWorkspaceDir("level-1-directory-1.1"),
+			),
+			NewWorkspaceSymlink("level-0-symlink-1", "level-0-directory-1"),
+		)).Purge()
+		MustWriteFile(path.Join(dir, "level-0-file-1.jpg"), "content")
+
+		eager := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))).Scan(context.TODO())))
+		getdents := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical), WithGetdents()))).Scan(context.TODO())))
+
+		Expect(getdents).To(HaveLen(len(eager)))
+		for i := range eager {
+			file, ok := getdents[i].FileInfo.(File)
+			Expect(ok).To(BeTrue())
+			Expect(file.Type()).To(Equal(eager[i].FileInfo.Mode().Type()))
+
+			Expect(file.PathName()).To(Equal(eager[i].FileInfo.PathName()))
+			Expect(file.IsDir()).To(Equal(eager[i].FileInfo.IsDir()))
+			Expect(file.Mode()).To(Equal(eager[i].FileInfo.Mode()))
+			Expect(file.Size()).To(Equal(eager[i].FileInfo.Size()))
+			Expect(file.ModTime()).To(Equal(eager[i].FileInfo.ModTime()))
+		}
+
+		Expect(getdents).To(HaveRegularFiles(2))
+		Expect(getdents).To(HaveDirectories(2))
+	}))
+
+	t.Run("When directory does not fit the buffer", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-getdents-in-bulks")
+		defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles(strings.Repeat("long-file-name-", 8), 2000)...)).Purge()
+
+		files := FileChanToSlice(MustScan(MustScanner(NewBasicScanner(WithDir(dir), WithGetdents())).Scan(context.TODO())))
+		Expect(files).To(HaveLen(2000))
+		Expect(files).To(HaveRegularFiles(2000))
+
+		names := make(map[string]bool, len(files))
+		for _, file := range files {
+			names[file.FileInfo.Name()] = true
+		}
+		Expect(names).To(HaveLen(2000))
+	}))
+
+	t.Run("When directory is empty", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("empty-directory-to-getdents")
+		defer MustNewWorkspace(dir).Purge()
+
+		files := FileChanToSlice(MustScan(MustScanner(NewBasicScanner(WithDir(dir), WithGetdents(), WithDirEvents())).Scan(context.TODO())))
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].Kind).To(Equal(KindDirStart))
+		Expect(files[1].Kind).To(Equal(KindDirEnd))
+	}))
+
+	t.Run("When reading through the filesystem", ScannerTest(func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"level-0-file-1.jpg":                       {Data: []byte("jpg")},
+			"level-0-directory-1/level-1-file-1.1.jpg": {},
+		}
+
+		s := MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(fsys), WithGetdents())))
+		files := FileChanToSlice(MustScan(s.Scan(context.TODO())))
+		Expect(files).To(HaveLen(3))
+		Expect(files).To(HaveRegularFiles(2))
+	}))
+}
@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	gitignoreFile   = ".gitignore"
+	gitDir          = ".git"
+	gitExcludesFile = ".git/info/exclude"
+)
+
+// WithGitignore skips the files git ignores. The .gitignore of every directory is loaded as the
+// directory is scanned, together with .git/info/exclude of the scanned one, see WithGitExcludesFile
+// for the global excludes. The precedence is the git one: the patterns of the deeper .gitignore win
+// over the shallower ones, those over the excludes, and the last matching pattern of a file wins.
+// Ignored directories are never read, so their files can't be re-included, the same as in git.
+// The .git directory itself is always skipped.
+func WithGitignore() RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.gitignore = true
+		return nil
+	}
+}
+
+// WithGitExcludesFile reads the global excludes (core.excludesFile of git) from the OS file and turns
+// WithGitignore on. The patterns are relative to the scanned directories.
+func WithGitExcludesFile(name string) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		s.gitignore = true
+		s.gitExcludes = parseIgnorePatterns(data)
+		return nil
+	}
+}
+
+// ignorePattern is a single line of the .gitignore file.
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// ignoreRules are the patterns of a single file, relative to the directory at base. Rules of the
+// parent directories are linked, the excludes are the outermost ones.
+type ignoreRules struct {
+	parent   *ignoreRules
+	base     string
+	patterns []ignorePattern
+}
+
+func parseIgnorePatterns(data []byte) []ignorePattern {
+	var patterns []ignorePattern
+
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		if p, ok := parseIgnorePattern(lines.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns
+}
+
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	var p ignorePattern
+
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || line[0] == '#' {
+		return p, false
+	}
+
+	// trailing spaces are ignored, unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line[0] == '!' {
+		p.negate, line = true, line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return p, false
+	}
+
+	// pattern without a slash matches at any level, with one it's relative to the .gitignore
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+
+	line = strings.TrimPrefix(line, "/")
+
+	p.segments = strings.Split(line, "/")
+	for i, segment := range p.segments {
+		if segment == "**" {
+			continue
+		}
+
+		segment = strings.ReplaceAll(segment, "[!", "[^")
+		if _, err := path.Match(segment, ""); err != nil {
+			// git skips the broken patterns as well
+			return p, false
+		}
+
+		p.segments[i] = segment
+	}
+
+	// trailing ** matches everything inside, but not the directory itself
+	if p.segments[len(p.segments)-1] == "**" {
+		p.segments = append(p.segments, "*")
+	}
+
+	return p, true
+}
+
+// ignores tells whether the file placed below the base of the rules is ignored.
+func (r *ignoreRules) ignores(relPath string, isDir bool) bool {
+	for ; r != nil; r = r.parent {
+		rel := relPath
+		if r.base != "." {
+			rel = relPath[len(r.base)+1:]
+		}
+
+		segments := strings.Split(rel, "/")
+		for i := len(r.patterns) - 1; i >= 0; i-- {
+			p := r.patterns[i]
+			if p.dirOnly && !isDir {
+				continue
+			}
+
+			if matchSegments(p.segments, segments) {
+				return !p.negate
+			}
+		}
+	}
+
+	return false
+}
+
+// ignored tells whether the item of the listing has to be skipped.
+func (r *ignoreRules) ignored(item FileItem) bool {
+	if item.Kind != KindEntry || item.FileInfo == nil {
+		return false
+	}
+
+	if item.FileInfo.Name() == gitDir {
+		return true
+	}
+
+	return r.ignores(item.FileInfo.RelPath(), item.FileInfo.IsDir())
+}
+
+// loadIgnoreRules extends the rules inherited by the directory with its own .gitignore. The scanned
+// directories get the excludes first.
+func (s *RecursiveScanner) loadIgnoreRules(dir *directory) error {
+	if dir.relPath == "." {
+		dir.ignore = nil
+		if len(s.gitExcludes) > 0 {
+			dir.ignore = &ignoreRules{base: ".", patterns: s.gitExcludes}
+		}
+
+		if err := s.readIgnoreRules(dir, gitExcludesFile); err != nil {
+			return err
+		}
+	}
+
+	return s.readIgnoreRules(dir, gitignoreFile)
+}
+
+func (s *RecursiveScanner) readIgnoreRules(dir *directory, name string) error {
+	data, err := s.template.fsys.ReadFile(path.Join(dir.path, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if patterns := parseIgnorePatterns(data); len(patterns) > 0 {
+		dir.ignore = &ignoreRules{parent: dir.ignore, base: dir.relPath, patterns: patterns}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestWithGitignore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":         {},
+		".git/info/exclude": {Data: []byte("# excluded locally\n*.bak\n")},
+		".gitignore": {Data: []byte(
+			"*.log\n" +
+				"!important.log\n" +
+				"!keep.bak\n" +
+				"/build/\n" +
+				"!build/keep.txt\n" +
+				"docs/**\n" +
+				"!docs/keep.md\n" +
+				"vendor/\n" +
+				"trailing.txt   \n",
+		)},
+		"README.md":            {},
+		"app.log":              {},
+		"important.log":        {},
+		"secret.bak":           {},
+		"keep.bak":             {},
+		"trailing.txt":         {},
+		"main.go.swp":          {},
+		"build/out.bin":        {},
+		"build/keep.txt":       {},
+		"docs/guide.md":        {},
+		"docs/keep.md":         {},
+		"vendor/lib/a.go":      {},
+		"src/.gitignore":       {Data: []byte("!debug.log\n*.tmp\n")},
+		"src/debug.log":        {},
+		"src/cache.tmp":        {},
+		"src/vendor":           {},
+		"src/build/main.go":    {},
+		"src/nested/cache.tmp": {},
+		"src/nested/other.log": {},
+		"src/nested/.git":      {Data: []byte("gitdir: ../../.git/modules/nested\n")},
+		"src/nested/vendor/go": {},
+	}
+
+	expected := []string{
+		".gitignore",
+		"README.md",
+		"docs",
+		"docs/keep.md",
+		"important.log",
+		"keep.bak",
+		"main.go.swp",
+		"src",
+		"src/.gitignore",
+		"src/build",
+		"src/build/main.go",
+		"src/debug.log",
+		"src/nested",
+		"src/vendor",
+	}
+
+	scan := func(options ...RecursiveScannerOptionFn) []string {
+		options = append(options, WithDirectories("."))
+		return SortedPathNames(FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(options...)).Scan(context.TODO()))))
+	}
+
+	t.Run("When ignored files are skipped", ScannerTest(func(t *testing.T) {
+		for _, options := range [][]RecursiveScannerOptionFn{
+			{WithScannerOptions(WithFS(fsys))},
+			{WithScannerOptions(WithFS(fsys), WithOrder(OrderLexical))},
+			{WithScannerOptions(WithFS(fsys), WithOrder(OrderLexical)), WithStrategy(StrategyBreadthFirst)},
+			{WithScannerOptions(WithFS(fsys), WithLazyStat()), WithQueueSpill(1, "")},
+		} {
+			Expect(scan(append(options, WithGitignore())...)).To(Equal(expected))
+		}
+	}))
+
+	t.Run("When gitignore is not enabled", ScannerTest(func(t *testing.T) {
+		Expect(scan(WithScannerOptions(WithFS(fsys)))).To(ContainElement("src/nested/other.log"))
+	}))
+
+	t.Run("When global excludes file is given", ScannerTest(func(t *testing.T) {
+		excludesFile := filepath.Join(t.TempDir(), "ignore")
+		Expect(os.WriteFile(excludesFile, []byte("*.swp\n*.md\n"), 0644)).To(Succeed())
+
+		// docs/keep.md is re-included by the .gitignore, which wins over the global excludes
+		Expect(scan(WithScannerOptions(WithFS(fsys)), WithGitExcludesFile(excludesFile))).To(Equal([]string{
+			".gitignore",
+			"docs",
+			"docs/keep.md",
+			"important.log",
+			"keep.bak",
+			"src",
+			"src/.gitignore",
+			"src/build",
+			"src/build/main.go",
+			"src/debug.log",
+			"src/nested",
+			"src/vendor",
+		}))
+	}))
+
+	t.Run("When global excludes file does not exist", ScannerTest(func(t *testing.T) {
+		_, err := NewRecursiveScanner(WithGitExcludesFile(filepath.Join(t.TempDir(), "ignore")))
+		Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+	}))
+
+	t.Run("When scanning the OS directory", ScannerTest(func(t *testing.T) {
+		dir := t.TempDir()
+		Expect(os.CopyFS(dir, fsys)).To(Succeed())
+
+		pathNames := FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithGitignore())).Scan(context.TODO())))
+		for i := range pathNames {
+			pathNames[i], _ = filepath.Rel(dir, pathNames[i])
+		}
+
+		Expect(SortedPathNames(pathNames)).To(Equal(expected))
+	}))
+
+	t.Run("When builder is given gitignore", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewBuilder().Recursive().Files().FS(fsys).In(".").Gitignore().MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{
+			".gitignore",
+			"README.md",
+			"docs/keep.md",
+			"important.log",
+			"keep.bak",
+			"main.go.swp",
+			"src/.gitignore",
+			"src/build/main.go",
+			"src/debug.log",
+			"src/vendor",
+		}))
+	}))
+}
@@ -0,0 +1,265 @@
+package scanner
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+const (
+	nameGlobFilter      = "GlobFilter"
+	nameGlobPruneFilter = "GlobPruneFilter"
+
+	// maxGlobAlternatives limits the patterns the braces expand to, every brace group multiplies them
+	maxGlobAlternatives = 1024
+)
+
+var ErrGlobTooComplex = errors.New("glob braces expand to too many patterns")
+
+// Glob is the compiled doublestar pattern, matched against the slash separated path relative to the
+// scan root, see FileInfo.RelPath. Besides the syntax of path.Match it supports:
+//
+//	**      any number of the directories, when it's the whole segment, e.g. src/**/testdata/*.json
+//	{a,b}   any of the alternatives, which may contain the slashes and nest, e.g. *.{jpg,png}
+//	[!a-z]  the negated class, the same as [^a-z]
+//
+// The braces may expand to 1024 patterns at most, CompileGlob returns ErrGlobTooComplex otherwise.
+type Glob struct {
+	pattern string
+	// alternatives are the segments of every pattern the braces expand to
+	alternatives [][]string
+}
+
+func CompileGlob(pattern string) (*Glob, error) {
+	expanded, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Glob{pattern: pattern}
+	for _, p := range expanded {
+		segments := strings.Split(p, "/")
+		for i, segment := range segments {
+			if segment == "**" {
+				continue
+			}
+
+			segment = strings.ReplaceAll(segment, "[!", "[^")
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, err
+			}
+
+			segments[i] = segment
+		}
+
+		g.alternatives = append(g.alternatives, segments)
+	}
+
+	return g, nil
+}
+
+func MustCompileGlob(pattern string) *Glob {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return g
+}
+
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// Match tells whether the relative path matches the pattern.
+func (g *Glob) Match(relPath string) bool {
+	segments := relSegments(relPath)
+	for _, alternative := range g.alternatives {
+		if matchSegments(alternative, segments) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchBelow tells whether any path below the relative directory could match the pattern.
+func (g *Glob) MatchBelow(relDir string) bool {
+	segments := relSegments(relDir)
+	for _, alternative := range g.alternatives {
+		if matchPrefix(alternative, segments) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// relSegments splits the relative path, the root itself has none.
+func relSegments(relPath string) []string {
+	if relPath == "." {
+		return nil
+	}
+
+	return strings.Split(relPath, "/")
+}
+
+func matchSegments(pattern, segments []string) bool {
+	m := segmentsMatcher{pattern: pattern, segments: segments}
+	return m.match(0, 0)
+}
+
+// segmentsMatcher matches the pattern from the given pattern & segment index onwards. Every **
+// tries the rest of the pattern at each of the following segments, so without remembering the
+// states already known not to match, the repeated ** would backtrack exponentially.
+type segmentsMatcher struct {
+	pattern  []string
+	segments []string
+	// failed is indexed by the pattern index * (len(segments) + 1) + the segment index
+	failed []bool
+}
+
+func (m *segmentsMatcher) match(p, s int) bool {
+	for p < len(m.pattern) {
+		if m.pattern[p] == "**" {
+			if m.failed == nil {
+				m.failed = make([]bool, (len(m.pattern)+1)*(len(m.segments)+1))
+			}
+
+			state := p*(len(m.segments)+1) + s
+			if m.failed[state] {
+				return false
+			}
+
+			// ** swallows as few directories as possible, backtracking for more
+			for i := s; i <= len(m.segments); i++ {
+				if m.match(p+1, i) {
+					return true
+				}
+			}
+
+			m.failed[state] = true
+			return false
+		}
+
+		if s == len(m.segments) {
+			return false
+		}
+
+		if ok, _ := path.Match(m.pattern[p], m.segments[s]); !ok {
+			return false
+		}
+
+		p, s = p+1, s+1
+	}
+
+	return s == len(m.segments)
+}
+
+// matchPrefix tells whether the segments could be followed by the ones making the whole path match.
+func matchPrefix(pattern, segments []string) bool {
+	for len(segments) > 0 {
+		if len(pattern) == 0 {
+			return false
+		}
+
+		if pattern[0] == "**" {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(pattern) > 0
+}
+
+// expandBraces turns the pattern into the patterns without braces, one for every alternative.
+func expandBraces(pattern string) ([]string, error) {
+	start, end, commas := -1, -1, []int(nil)
+
+	depth := 0
+	for i := 0; i < len(pattern) && end < 0; i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			// braces & commas inside of the class are literal
+			if j := strings.IndexByte(pattern[i+1:], ']'); j >= 0 {
+				i += j + 1
+			}
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				return nil, path.ErrBadPattern
+			}
+
+			if depth--; depth == 0 {
+				end = i
+			}
+		}
+	}
+
+	if depth > 0 {
+		return nil, path.ErrBadPattern
+	}
+
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+
+	var (
+		expanded []string
+		from     = start + 1
+	)
+
+	for _, to := range append(commas, end) {
+		// the rest of the pattern may have the braces too
+		alternatives, err := expandBraces(pattern[:start] + pattern[from:to] + pattern[end+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		// checked as the product grows, so it's never built in full
+		if expanded = append(expanded, alternatives...); len(expanded) > maxGlobAlternatives {
+			return nil, ErrGlobTooComplex
+		}
+
+		from = to + 1
+	}
+
+	return expanded, nil
+}
+
+// GlobFilter matches the files, which paths relative to the scan root match the glob.
+func GlobFilter(g *Glob) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		return g.Match(file.FileInfo.RelPath())
+	}), nameGlobFilter)
+}
+
+// GlobPruneFilter matches the directories, below which nothing can match the glob, see WithPrune.
+func GlobPruneFilter(g *Glob) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		return !g.MatchBelow(file.FileInfo.RelPath())
+	}), nameGlobPruneFilter)
+}
@@ -0,0 +1,175 @@
+package scanner_test
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestCompileGlob(t *testing.T) {
+	t.Run("When pattern is invalid", ScannerTest(func(t *testing.T) {
+		for _, pattern := range []string{"[a-", "*.{jpg,png", "*.jpg}", "src/[!a"} {
+			_, err := CompileGlob(pattern)
+			Expect(err).To(Equal(path.ErrBadPattern), pattern)
+		}
+	}))
+
+	t.Run("When pattern is valid", ScannerTest(func(t *testing.T) {
+		g, err := CompileGlob("src/**/testdata/*.json")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(g.String()).To(Equal("src/**/testdata/*.json"))
+	}))
+
+	t.Run("When braces expand to too many patterns", ScannerTest(func(t *testing.T) {
+		_, err := CompileGlob(strings.Repeat("{a,b}", 10))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = CompileGlob(strings.Repeat("{a,b}", 11))
+		Expect(err).To(Equal(ErrGlobTooComplex))
+
+		_, err = CompileGlob(strings.Repeat("{a,b,c,d}/", 32) + "*.go")
+		Expect(err).To(Equal(ErrGlobTooComplex))
+	}))
+}
+
+func TestGlobMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		relPath string
+		match   bool
+	}{
+		{"*.json", "a.json", true},
+		{"*.json", "src/a.json", false},
+		{"src/*/a.json", "src/pkg/a.json", true},
+		{"src/*/a.json", "src/a.json", false},
+		{"src/**/testdata/*.json", "src/testdata/a.json", true},
+		{"src/**/testdata/*.json", "src/pkg/scanner/testdata/a.json", true},
+		{"src/**/testdata/*.json", "src/pkg/testdata/nested/a.json", false},
+		{"src/**/testdata/*.json", "lib/testdata/a.json", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/main/main.go", true},
+		{"src/**", "src", true},
+		{"src/**", "src/pkg/a.go", true},
+		{"src/**", "lib/a.go", false},
+		{"**", ".", true},
+		{"*", ".", false},
+		{"a**b", "axxb", true},
+		{"*.{jpg,png}", "photo.png", true},
+		{"*.{jpg,png}", "photo.gif", false},
+		{"{src,lib/{a,b}}/*.go", "lib/b/main.go", true},
+		{"{src,lib/{a,b}}/*.go", "lib/c/main.go", false},
+		{"file-[0-9].txt", "file-7.txt", true},
+		{"file-[!0-9].txt", "file-7.txt", false},
+		{"file-[!0-9].txt", "file-x.txt", true},
+		{"file-[{,}].txt", "file-,.txt", true},
+		{"\\{a,b\\}", "{a,b}", true},
+	} {
+		t.Run("When "+tc.pattern+" is matched against "+tc.relPath, ScannerTest(func(t *testing.T) {
+			Expect(MustCompileGlob(tc.pattern).Match(tc.relPath)).To(Equal(tc.match))
+		}))
+	}
+
+	t.Run("When ** is repeated", ScannerTest(func(t *testing.T) {
+		g := MustCompileGlob(strings.Repeat("**/a/", 20) + "b")
+		relPath := strings.Repeat("a/", 40) + "c"
+
+		Expect(g.Match(relPath)).To(BeFalse())
+		Expect(g.Match(strings.Repeat("a/", 40) + "b")).To(BeTrue())
+	}))
+}
+
+func TestGlobMatchBelow(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		relDir  string
+		match   bool
+	}{
+		{"*.json", ".", true},
+		{"*.json", "src", false},
+		{"src/**/testdata/*.json", "src", true},
+		{"src/**/testdata/*.json", "src/pkg/scanner", true},
+		{"src/**/testdata/*.json", "lib", false},
+		{"src/*/a.json", "src/pkg", true},
+		{"src/*/a.json", "src/pkg/nested", false},
+		{"{src,lib}/*.go", "lib", true},
+		{"{src,lib}/*.go", "docs", false},
+		{"**/*.go", "docs/nested", true},
+	} {
+		t.Run("When "+tc.pattern+" is matched below "+tc.relDir, ScannerTest(func(t *testing.T) {
+			Expect(MustCompileGlob(tc.pattern).MatchBelow(tc.relDir)).To(Equal(tc.match))
+		}))
+	}
+}
+
+func TestGlobFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":                          {},
+		"src/main.go":                        {},
+		"src/testdata/a.json":                {},
+		"src/pkg/scanner/testdata/b.json":    {},
+		"src/pkg/scanner/testdata/c.txt":     {},
+		"lib/testdata/d.json":                {},
+		"node_modules/src/testdata/e.json":   {},
+		"node_modules/dependency/index.json": {},
+	}
+
+	scan := func(options ...RecursiveScannerOptionFn) []string {
+		options = append(options, WithDirectories("."), WithScannerOptions(WithFS(fsys)))
+		return SortedPathNames(FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(options...)).Scan(context.TODO()))))
+	}
+
+	g := MustCompileGlob("src/**/testdata/*.json")
+
+	t.Run("When glob filter is applied", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewFilterScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(fsys)))),
+			GlobFilter(g),
+		).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{
+			"src/pkg/scanner/testdata/b.json",
+			"src/testdata/a.json",
+		}))
+	}))
+
+	t.Run("When glob prunes the subtrees", ScannerTest(func(t *testing.T) {
+		Expect(scan(WithPrune(GlobPruneFilter(g)))).To(Equal([]string{
+			"README.md",
+			"lib",
+			"node_modules",
+			"src",
+			"src/main.go",
+			"src/pkg",
+			"src/pkg/scanner",
+			"src/pkg/scanner/testdata",
+			"src/pkg/scanner/testdata/b.json",
+			"src/pkg/scanner/testdata/c.txt",
+			"src/testdata",
+			"src/testdata/a.json",
+		}))
+	}))
+
+	t.Run("When builder is given the globs", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewBuilder().Recursive().Files().FS(fsys).In(".").Glob("src/**/testdata/*.json", "lib/**/*.json").MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{
+			"lib/testdata/d.json",
+			"src/pkg/scanner/testdata/b.json",
+			"src/testdata/a.json",
+		}))
+	}))
+
+	t.Run("When builder is given an invalid glob", ScannerTest(func(t *testing.T) {
+		_, err := NewBuilder().Recursive().In(".").Glob("*.{jpg").Build()
+
+		Expect(err).To(Equal(path.ErrBadPattern))
+	}))
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"context"
+	"iter"
+)
+
+// Iterable is implemented by the scanners able to iterate without the channels, so breaking out
+// of the loop stops the scanning right away.
+type Iterable interface {
+	All(ctx context.Context) iter.Seq2[FileInfo, error]
+}
+
+// itemsSource is the native iterator of the built-in scanners. Unlike Iterable it keeps the
+// directory events, so the wrappers can pass them through.
+type itemsSource interface {
+	items(ctx context.Context) iter.Seq[FileItem]
+}
+
+// All iterates over the files found by the scanner. Errors are reported along the way, the
+// directory events are skipped. Breaking out of the loop stops the scanner and all its goroutines.
+func All(ctx context.Context, s Scanner) iter.Seq2[FileInfo, error] {
+	if it, ok := s.(Iterable); ok {
+		return it.All(ctx)
+	}
+
+	return entries(items(ctx, s))
+}
+
+// items iterates natively over the built-in scanners and over the channel of any other one.
+func items(ctx context.Context, s Scanner) iter.Seq[FileItem] {
+	if src, ok := s.(itemsSource); ok {
+		return src.items(ctx)
+	}
+
+	return scanItems(ctx, s)
+}
+
+func scanItems(ctx context.Context, s Scanner) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		// scanner goroutines exit once the context is done, there is no need to drain the channel
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fileChan, err := s.Scan(ctx)
+		if err != nil {
+			yield(FileItem{Err: err})
+			return
+		}
+
+		for item := range fileChan {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func entries(items iter.Seq[FileItem]) iter.Seq2[FileInfo, error] {
+	return func(yield func(FileInfo, error) bool) {
+		for item := range items {
+			if item.Kind != KindEntry {
+				continue
+			}
+
+			if !yield(item.FileInfo, item.Err) {
+				return
+			}
+		}
+	}
+}
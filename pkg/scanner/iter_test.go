@@ -0,0 +1,199 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func SeqToPathNames(seq iter.Seq2[FileInfo, error]) []string {
+	var pathNames []string
+
+	for info, err := range seq {
+		Expect(err).ToNot(HaveOccurred())
+		pathNames = append(pathNames, info.PathName())
+	}
+
+	return pathNames
+}
+
+// EntryPathNames skips the directory events, the same way All does.
+func EntryPathNames(fileChan FileItemChan) []string {
+	var pathNames []string
+
+	for item := range fileChan {
+		if item.Kind == KindEntry {
+			pathNames = append(pathNames, item.String())
+		}
+	}
+
+	return pathNames
+}
+
+func SortedPathNames(pathNames []string) []string {
+	sort.Strings(pathNames)
+	return pathNames
+}
+
+func TestAll(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-iterate")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFiles("level-2-file-1.2", 3)...,
+			),
+		),
+		NewWorkspaceDir("level-0-directory-2",
+			NewWorkspaceFiles("level-1-file-2", 5)...,
+		),
+	)).Purge()
+
+	t.Run("When scanner fails", ScannerTest(func(t *testing.T) {
+		var errs []error
+		for info, err := range All(context.TODO(), &FailingScanner{}) {
+			Expect(info).To(BeNil())
+			errs = append(errs, err)
+		}
+
+		Expect(errs).To(HaveLen(1))
+	}))
+
+	t.Run("When scanner is not iterable", ScannerTest(func(t *testing.T) {
+		var s Scanner = &SuccessfulScanner{items: []FileItem{{}, {}, {}}}
+		_, ok := s.(Iterable)
+		Expect(ok).To(BeFalse())
+
+		var count int
+		for range All(context.TODO(), s) {
+			count++
+		}
+
+		Expect(count).To(Equal(3))
+	}))
+
+	scanners := map[string]func() Scanner{
+		"BasicScanner": func() Scanner {
+			return MustScanner(NewBasicScanner(WithDir(dir), WithDirEvents()))
+		},
+		"FilterScanner": func() Scanner {
+			return NewFilterRegularFilesScanner(MustScanner(NewBasicScanner(WithDir(dir))))
+		},
+		"DebugScanner": func() Scanner {
+			return NewDebugScanner(MustScanner(NewBasicScanner(WithDir(dir))), func(FileItem) {})
+		},
+		"MultiScanner": func() Scanner {
+			return NewMultiScanner(
+				MustScanner(NewBasicScanner(WithDir(dir))),
+				MustScanner(NewBasicScanner(WithDir(dir+"/level-0-directory-2"))),
+			)
+		},
+		"ordered MultiScanner": func() Scanner {
+			return NewOrderedMultiScanner(
+				OrderLexical,
+				MustScanner(NewBasicScanner(WithDir(dir), WithOrder(OrderLexical))),
+				MustScanner(NewBasicScanner(WithDir(dir+"/level-0-directory-2"), WithOrder(OrderLexical))),
+			)
+		},
+		"RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(1)))
+		},
+		"RecursiveScanner with many workers": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4)))
+		},
+	}
+
+	for name, newScanner := range scanners {
+		t.Run(fmt.Sprintf("When iterating over %s", name), ScannerTest(func(t *testing.T) {
+			s := newScanner()
+			expected := EntryPathNames(MustScan(s.Scan(context.TODO())))
+
+			_, ok := s.(Iterable)
+			Expect(ok).To(BeTrue())
+			Expect(SortedPathNames(SeqToPathNames(All(context.TODO(), s)))).To(Equal(SortedPathNames(expected)))
+		}))
+
+		t.Run(fmt.Sprintf("When breaking out of %s", name), ScannerTest(func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			for range All(context.TODO(), newScanner()) {
+				break
+			}
+
+			Eventually(runtime.NumGoroutine, time.Second).Should(BeNumerically("<=", goroutines))
+		}))
+	}
+
+	t.Run("When iterating natively", ScannerTest(func(t *testing.T) {
+		goroutines := runtime.NumGoroutine()
+
+		s := NewFilterRegularFilesScanner(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(1))))
+		for range All(context.TODO(), s) {
+			Expect(runtime.NumGoroutine()).To(Equal(goroutines))
+		}
+	}))
+
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var count int
+		for range All(ctx, MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(1)))) {
+			count++
+			cancel()
+		}
+
+		Expect(count).To(BeNumerically("<", 13))
+	}))
+}
+
+func TestRecursiveScannerAll(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-iterate-in-order")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFiles("level-2-file-1.2", 3)...,
+			),
+			NewWorkspaceDir("level-1-directory-1.3"),
+		),
+		NewWorkspaceDir("level-0-directory-2",
+			NewWorkspaceFiles("level-1-file-2", 5)...,
+		),
+	)).Purge()
+
+	for _, strategy := range []Strategy{StrategyDepthFirst, StrategyBreadthFirst} {
+		t.Run(fmt.Sprintf("When strategy is %d", strategy), ScannerTest(func(t *testing.T) {
+			options := []RecursiveScannerOptionFn{
+				WithDirectories(dir),
+				WithStrategy(strategy),
+				WithMinDepth(2),
+				WithScannerOptions(WithOrder(OrderLexical), WithDirEvents()),
+			}
+
+			s := MustScanner(NewRecursiveScanner(append(options, WithWorkers(1))...))
+			expected := EntryPathNames(MustScan(MustScanner(NewRecursiveScanner(options...)).Scan(context.TODO())))
+
+			Expect(expected).To(HaveLen(11))
+			Expect(SeqToPathNames(All(context.TODO(), s))).To(Equal(expected))
+		}))
+	}
+	t.Run("When queue is limited", ScannerTest(func(t *testing.T) {
+		options := []RecursiveScannerOptionFn{WithDirectories(dir), WithQueueLimit(1), WithScannerOptions(WithDirEvents())}
+
+		s := MustScanner(NewRecursiveScanner(append(options, WithWorkers(1))...))
+		expected := EntryPathNames(MustScan(MustScanner(NewRecursiveScanner(options...)).Scan(context.TODO())))
+
+		Expect(expected).To(HaveLen(14))
+		Expect(SortedPathNames(SeqToPathNames(All(context.TODO(), s)))).To(Equal(SortedPathNames(expected)))
+	}))
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// lazyFileInfo stats the file the first time anything but the name or the type is needed. The
+// stat is shared by all the copies of the item.
+type lazyFileInfo struct {
+	entry fs.DirEntry
+
+	once sync.Once
+	info os.FileInfo
+	err  error
+}
+
+func newLazyFileInfo(entry fs.DirEntry) *lazyFileInfo {
+	return &lazyFileInfo{entry: entry}
+}
+
+func (i *lazyFileInfo) Info() (os.FileInfo, error) {
+	i.once.Do(func() {
+		i.info, i.err = i.entry.Info()
+	})
+
+	return i.info, i.err
+}
+
+func (i *lazyFileInfo) Name() string {
+	return i.entry.Name()
+}
+
+func (i *lazyFileInfo) IsDir() bool {
+	return i.entry.IsDir()
+}
+
+func (i *lazyFileInfo) Type() fs.FileMode {
+	return i.entry.Type()
+}
+
+// Mode falls back to the type, when the file is gone before the stat.
+func (i *lazyFileInfo) Mode() os.FileMode {
+	if info, err := i.Info(); err == nil {
+		return info.Mode()
+	}
+
+	return i.entry.Type()
+}
+
+func (i *lazyFileInfo) Size() int64 {
+	if info, err := i.Info(); err == nil {
+		return info.Size()
+	}
+
+	return 0
+}
+
+func (i *lazyFileInfo) ModTime() time.Time {
+	if info, err := i.Info(); err == nil {
+		return info.ModTime()
+	}
+
+	return time.Time{}
+}
+
+func (i *lazyFileInfo) Sys() interface{} {
+	if info, err := i.Info(); err == nil {
+		return info.Sys()
+	}
+
+	return nil
+}
+
+// fileType is the type bits of the mode, which don't need the stat in the lazy-stat mode.
+func fileType(info os.FileInfo) fs.FileMode {
+	if t, ok := info.(interface{ Type() fs.FileMode }); ok {
+		return t.Type()
+	}
+
+	return info.Mode().Type()
+}
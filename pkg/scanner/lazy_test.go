@@ -0,0 +1,146 @@
+package scanner_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// StatCountingFS counts the stats of the directory entries.
+type StatCountingFS struct {
+	fstest.MapFS
+	stats *int32
+}
+
+func (fsys StatCountingFS) Open(name string) (fs.File, error) {
+	f, err := fsys.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := f.(fs.ReadDirFile); ok {
+		return statCountingDir{d, fsys.stats}, nil
+	}
+
+	return f, nil
+}
+
+type statCountingDir struct {
+	fs.ReadDirFile
+	stats *int32
+}
+
+func (d statCountingDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+	for i, entry := range entries {
+		entries[i] = statCountingEntry{entry, d.stats}
+	}
+
+	return entries, err
+}
+
+type statCountingEntry struct {
+	fs.DirEntry
+	stats *int32
+}
+
+func (e statCountingEntry) Info() (fs.FileInfo, error) {
+	atomic.AddInt32(e.stats, 1)
+	return e.DirEntry.Info()
+}
+
+func TestWithLazyStat(t *testing.T) {
+	fsys := fstest.MapFS{
+		"level-0-file-1.jpg":                       {Data: []byte("jpg")},
+		"level-0-file-2.png":                       {Data: []byte("png file")},
+		"level-0-directory-1/level-1-file-1.1.jpg": {Data: []byte("jpg file")},
+		"level-0-directory-1/level-1-file-1.2.txt": {},
+		"level-0-directory-2/level-1-file-2.1.jpg": {},
+	}
+
+	t.Run("When filtering by the names and types", ScannerTest(func(t *testing.T) {
+		var stats int32
+
+		s := NewFilterScanner(
+			MustScanner(NewRecursiveScanner(
+				WithDirectories("."),
+				WithScannerOptions(WithFS(StatCountingFS{fsys, &stats}), WithLazyStat()),
+			)),
+			AndFilter(RegularFilesFilter, OrFilter(ExtensionFilter(".jpg"), RegExpFilter(regexp.MustCompile(`\.png$`)))),
+		)
+
+		files := FileChanToSlice(MustScan(s.Scan(context.TODO())))
+		Expect(files).To(HaveLen(4))
+		Expect(stats).To(BeZero())
+
+		for _, file := range files {
+			if file.FileInfo.PathName() == "level-0-directory-1/level-1-file-1.1.jpg" {
+				Expect(file.FileInfo.Size()).To(Equal(int64(8)))
+				Expect(file.FileInfo.Size()).To(Equal(int64(8)))
+			}
+		}
+
+		Expect(stats).To(Equal(int32(1)))
+	}))
+
+	t.Run("When lazy stat is not enabled", ScannerTest(func(t *testing.T) {
+		var stats int32
+
+		s := MustScanner(NewRecursiveScanner(WithDirectories("."), WithScannerOptions(WithFS(StatCountingFS{fsys, &stats}))))
+		Expect(FileChanToSlice(MustScan(s.Scan(context.TODO())))).To(HaveLen(7))
+		Expect(stats).To(Equal(int32(7)))
+	}))
+
+	t.Run("When scanning the OS", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-stat-lazily")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceFile("level-0-file-1.jpg"),
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceFile("level-1-file-1.1.jpg"),
+			),
+			NewWorkspaceSymlink("level-0-symlink-1", "level-0-directory-1"),
+		)).Purge()
+		MustWriteFile(path.Join(dir, "level-0-file-1.jpg"), "content")
+
+		eager := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))).Scan(context.TODO())))
+		lazy := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical), WithLazyStat()))).Scan(context.TODO())))
+
+		Expect(lazy).To(HaveLen(len(eager)))
+		for i := range eager {
+			Expect(lazy[i].FileInfo.PathName()).To(Equal(eager[i].FileInfo.PathName()))
+			Expect(lazy[i].FileInfo.IsDir()).To(Equal(eager[i].FileInfo.IsDir()))
+			Expect(lazy[i].FileInfo.Mode()).To(Equal(eager[i].FileInfo.Mode()))
+			Expect(lazy[i].FileInfo.Size()).To(Equal(eager[i].FileInfo.Size()))
+			Expect(lazy[i].FileInfo.ModTime()).To(Equal(eager[i].FileInfo.ModTime()))
+		}
+
+		Expect(lazy).To(HaveRegularFiles(2))
+		Expect(lazy).To(HaveDirectories(1))
+	}))
+
+	t.Run("When file is removed before the stat", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-with-removed-file")
+		defer MustNewWorkspace(dir, WithItems(NewWorkspaceFile("level-0-file-1.jpg"))).Purge()
+
+		files := FileChanToSlice(MustScan(MustScanner(NewBasicScanner(WithDir(dir), WithLazyStat())).Scan(context.TODO())))
+		Expect(files).To(HaveLen(1))
+		Expect(os.Remove(files[0].FileInfo.PathName())).To(Succeed())
+
+		file, ok := files[0].FileInfo.(File)
+		Expect(ok).To(BeTrue())
+
+		_, err := file.Info()
+		Expect(err).To(HaveOccurred())
+		Expect(file.Type().IsRegular()).To(BeTrue())
+		Expect(file.Size()).To(BeZero())
+		Expect(file.ModTime().IsZero()).To(BeTrue())
+	}))
+}
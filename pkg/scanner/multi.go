@@ -2,26 +2,42 @@ package scanner
 
 import (
 	"context"
+	"iter"
 	"sync"
 )
 
 type MultiScanner struct {
 	scanners []Scanner
+	order    Order
 }
 
 func NewMultiScanner(scanners ...Scanner) *MultiScanner {
 	return &MultiScanner{scanners: scanners}
 }
 
+// NewOrderedMultiScanner merges the scanners deterministically, assuming every one of them emits
+// its items in the same order already.
+func NewOrderedMultiScanner(order Order, scanners ...Scanner) *MultiScanner {
+	return &MultiScanner{scanners: scanners, order: order}
+}
+
 func (ms *MultiScanner) Scan(ctx context.Context) (FileItemChan, error) {
+	if ms.order != OrderNone {
+		return ms.scanOrdered(ctx)
+	}
+
 	var (
 		fileChan = make(FileItemChan)
 		wg       sync.WaitGroup
 	)
 
+	ctx, cancel := context.WithCancel(ctx)
+
 	for _, s := range ms.scanners {
 		ch, err := s.Scan(ctx)
 		if err != nil {
+			// stop the scanners started already
+			cancel()
 			return nil, err
 		}
 
@@ -30,15 +46,241 @@ func (ms *MultiScanner) Scan(ctx context.Context) (FileItemChan, error) {
 			defer wg.Done()
 
 			for item := range ch {
-				fileChan <- item
+				if !send(ctx, fileChan, item) {
+					return
+				}
 			}
 		}(ch)
 	}
 
 	go func() {
+		defer cancel()
+
 		wg.Wait()
 		close(fileChan)
 	}()
 
 	return fileChan, nil
 }
+
+// ScanBatches passes the batches of the scanners through, unless they have to be merged.
+func (ms *MultiScanner) ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error) {
+	var (
+		batchChan  = make(FileItemBatchChan)
+		batchChans []FileItemBatchChan
+		wg         sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	for _, s := range ms.scanners {
+		ch, err := ScanBatches(ctx, s, size)
+		if err != nil {
+			// stop the scanners started already
+			cancel()
+			return nil, err
+		}
+
+		batchChans = append(batchChans, ch)
+	}
+
+	if ms.order != OrderNone {
+		go func() {
+			defer cancel()
+			ms.mergeBatches(ctx, batchChans, newBatcher(ctx, batchChan, size))
+		}()
+
+		return batchChan, nil
+	}
+
+	for _, ch := range batchChans {
+		wg.Add(1)
+		go func(ch FileItemBatchChan) {
+			defer wg.Done()
+
+			for batch := range ch {
+				if !sendBatch(ctx, batchChan, batch) {
+					return
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		defer cancel()
+
+		wg.Wait()
+		close(batchChan)
+	}()
+
+	return batchChan, nil
+}
+
+func (ms *MultiScanner) mergeBatches(ctx context.Context, batchChans []FileItemBatchChan, b *batcher) {
+	defer close(b.batchChan)
+
+	var (
+		batches = make([][]FileItem, len(batchChans))
+		heads   = make([]FileItem, len(batchChans))
+		opened  = make([]bool, len(batchChans))
+	)
+
+	next := func(i int) {
+		for len(batches[i]) == 0 {
+			if batches[i], opened[i] = <-batchChans[i]; !opened[i] {
+				return
+			}
+		}
+
+		heads[i], batches[i] = batches[i][0], batches[i][1:]
+	}
+
+	for i := range batchChans {
+		next(i)
+	}
+
+	for {
+		n := -1
+		for i := range batchChans {
+			if opened[i] && (n < 0 || ms.order.compareItems(heads[i], heads[n]) < 0) {
+				n = i
+			}
+		}
+
+		if n < 0 {
+			b.flush()
+			return
+		}
+
+		if b.emit(heads[n]) == walkStop {
+			return
+		}
+
+		next(n)
+	}
+}
+
+// All iterates over the scanners one after another, or merges them when the order is set.
+func (ms *MultiScanner) All(ctx context.Context) iter.Seq2[FileInfo, error] {
+	return entries(ms.items(ctx))
+}
+
+func (ms *MultiScanner) items(ctx context.Context) iter.Seq[FileItem] {
+	if ms.order != OrderNone {
+		return ms.mergeItems(ctx)
+	}
+
+	return func(yield func(FileItem) bool) {
+		for _, s := range ms.scanners {
+			for item := range items(ctx, s) {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (ms *MultiScanner) walk(ctx context.Context, emit emitFn) {
+	// merged scanners can't be walked one by one
+	if ms.order != OrderNone {
+		walkItems(ctx, ms, emit)
+		return
+	}
+
+	for _, s := range ms.scanners {
+		if ctx.Err() != nil {
+			return
+		}
+
+		walk(ctx, s, emit)
+	}
+}
+
+func (ms *MultiScanner) mergeItems(ctx context.Context) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		var (
+			nexts  = make([]func() (FileItem, bool), len(ms.scanners))
+			heads  = make([]FileItem, len(ms.scanners))
+			opened = make([]bool, len(ms.scanners))
+		)
+
+		for i, s := range ms.scanners {
+			next, stop := iter.Pull(items(ctx, s))
+			defer stop()
+
+			nexts[i] = next
+			heads[i], opened[i] = next()
+		}
+
+		for {
+			next := -1
+			for i := range ms.scanners {
+				if opened[i] && (next < 0 || ms.order.compareItems(heads[i], heads[next]) < 0) {
+					next = i
+				}
+			}
+
+			if next < 0 || !yield(heads[next]) {
+				return
+			}
+
+			heads[next], opened[next] = nexts[next]()
+		}
+	}
+}
+
+func (ms *MultiScanner) scanOrdered(ctx context.Context) (FileItemChan, error) {
+	var (
+		fileChan = make(FileItemChan)
+		chans    []FileItemChan
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	for _, s := range ms.scanners {
+		ch, err := s.Scan(ctx)
+		if err != nil {
+			// stop the scanners started already
+			cancel()
+			return nil, err
+		}
+
+		chans = append(chans, ch)
+	}
+
+	go func() {
+		defer cancel()
+		defer close(fileChan)
+
+		var (
+			heads  = make([]FileItem, len(chans))
+			opened = make([]bool, len(chans))
+		)
+
+		for i, ch := range chans {
+			heads[i], opened[i] = <-ch
+		}
+
+		for {
+			next := -1
+			for i := range chans {
+				if opened[i] && (next < 0 || ms.order.compareItems(heads[i], heads[next]) < 0) {
+					next = i
+				}
+			}
+
+			if next < 0 {
+				return
+			}
+
+			if !send(ctx, fileChan, heads[next]) {
+				return
+			}
+
+			heads[next], opened[next] = <-chans[next]
+		}
+	}()
+
+	return fileChan, nil
+}
@@ -2,7 +2,9 @@ package scanner_test
 
 import (
 	"context"
+	"runtime/pprof"
 	"testing"
+	"time"
 
 	"errors"
 
@@ -111,3 +113,49 @@ func TestMultiScanner(t *testing.T) {
 		Expect(fileChan).To(WithTransform(FileChanToSlice, HaveLen(6)))
 	}))
 }
+
+func TestMultiScannerCancel(t *testing.T) {
+	firstDir := NewDirectoryPath("first-directory-to-cancel")
+	defer MustNewWorkspace(firstDir, WithItems(NewWorkspaceFiles("file", 20)...)).Purge()
+
+	secondDir := NewDirectoryPath("second-directory-to-cancel")
+	defer MustNewWorkspace(secondDir, WithItems(NewWorkspaceFiles("file", 20)...)).Purge()
+
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		ExpectCancelToStopScan(NewMultiScanner(
+			MustScanner(NewBasicScanner(WithDir(firstDir))),
+			MustScanner(NewBasicScanner(WithDir(secondDir))),
+		))
+	}))
+
+	t.Run("When context is cancelled and scanners are ordered", ScannerTest(func(t *testing.T) {
+		ExpectCancelToStopScan(NewOrderedMultiScanner(
+			OrderLexical,
+			MustScanner(NewBasicScanner(WithDir(firstDir), WithOrder(OrderLexical))),
+			MustScanner(NewBasicScanner(WithDir(secondDir), WithOrder(OrderLexical))),
+		))
+	}))
+
+	t.Run("When second scanner fails", ScannerTest(func(t *testing.T) {
+		for name, s := range map[string]*MultiScanner{
+			"unordered": NewMultiScanner(MustScanner(NewBasicScanner(WithDir(firstDir))), &FailingScanner{}),
+			"ordered": NewOrderedMultiScanner(
+				OrderLexical,
+				MustScanner(NewBasicScanner(WithDir(firstDir), WithOrder(OrderLexical))),
+				&FailingScanner{},
+			),
+		} {
+			label := "failing-" + name
+			pprof.Do(context.Background(), pprof.Labels("scan", label), func(ctx context.Context) {
+				_, err := s.Scan(ctx)
+				Expect(err).To(HaveOccurred())
+
+				_, err = s.ScanBatches(ctx, 4)
+				Expect(err).To(HaveOccurred())
+			})
+
+			// the first scanner is stopped, even though nobody reads it
+			Eventually(func() int { return LabeledGoroutines("scan", label) }, time.Second).Should(BeZero())
+		}
+	}))
+}
@@ -0,0 +1,328 @@
+package scanner
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"path"
+	"sync"
+)
+
+var (
+	ErrOpenatNotSupported = errors.New("openat traversal is not supported")
+	ErrDirReplaced        = errors.New("directory has been replaced")
+)
+
+// Dir is the directory opened in the openat mode, see WithOpenat. The files found in it are opened
+// relative to it, so neither a directory swapped for a symlink nor a path longer than PATH_MAX gets
+// in the way.
+//
+// Scanner closes the directory once it's done with it, or sooner when too many directories are
+// open. Dir is reopened relative to its parent whenever it's used again, and fails with
+// ErrDirReplaced when it's not the same directory anymore.
+type Dir struct {
+	name   string
+	parent *Dir
+	cache  *dirCache
+
+	// the fields below are guarded by the cache
+
+	file *os.File
+	// dev & ino identify the directory once opened, it's checked whenever it's reopened
+	dev, ino   uint64
+	identified bool
+	// refs are the listing of the directory & its subdirectories waiting to be opened relative to it
+	refs int
+	// pins are the calls using the file right now, the directory is never closed under them
+	pins int
+	// idle is the element of the cache, set while the file is open, but not pinned
+	idle *list.Element
+}
+
+// Name is the path the directory has been found at.
+func (d *Dir) Name() string {
+	return d.name
+}
+
+// Open opens the file placed directly in the directory for reading, it fails on a symlink.
+func (d *Dir) Open(name string) (*os.File, error) {
+	var f *os.File
+	err := d.use(func(dir *os.File) (err error) {
+		f, err = openFileAt(dir, name)
+		return err
+	})
+
+	return f, err
+}
+
+// OpenDir opens the directory placed directly in the directory, it fails on a symlink. Close it
+// once done.
+func (d *Dir) OpenDir(name string) (*Dir, error) {
+	dir, err := d.openDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir.unpin()
+	return dir, nil
+}
+
+// Lstat stats the file placed directly in the directory, without following the symlink.
+func (d *Dir) Lstat(name string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := d.use(func(dir *os.File) (err error) {
+		info, err = lstatAt(dir, name)
+		return err
+	})
+
+	return info, err
+}
+
+// Close closes the directory returned by OpenDir. The Dir of an item belongs to the scanner, it is
+// never closed by the caller.
+func (d *Dir) Close() error {
+	d.release()
+	return nil
+}
+
+// openRootDir opens the directory given by the path, the scanned one is trusted as is. Cache is the
+// one of the scan, nil means no limit. Dir is returned pinned & referenced by its listing.
+func openRootDir(name string, cache *dirCache) (*Dir, error) {
+	if cache == nil {
+		cache = newDirCache(0)
+	}
+
+	dir := &Dir{name: name, cache: cache, refs: 1}
+	if _, err := dir.pin(); err != nil {
+		return nil, err
+	}
+
+	return dir, nil
+}
+
+// openDir opens the subdirectory, returned pinned & referenced by its listing.
+func (d *Dir) openDir(name string) (*Dir, error) {
+	dir := &Dir{name: path.Join(d.name, name), parent: d, cache: d.cache, refs: 1}
+	if _, err := dir.pin(); err != nil {
+		return nil, err
+	}
+
+	return dir, nil
+}
+
+// use runs fn with the open directory.
+func (d *Dir) use(fn func(f *os.File) error) error {
+	f, err := d.pin()
+	if err != nil {
+		return err
+	}
+
+	defer d.unpin()
+	return fn(f)
+}
+
+// retain keeps the directory around for the subdirectory, which is opened relative to it later.
+func (d *Dir) retain() *Dir {
+	if d != nil {
+		d.cache.mu.Lock()
+		d.refs++
+		d.cache.mu.Unlock()
+	}
+
+	return d
+}
+
+// release drops the reference, the directory is closed once none is left and it's not used.
+func (d *Dir) release() {
+	if d == nil {
+		return
+	}
+
+	var f *os.File
+
+	d.cache.mu.Lock()
+	if d.refs--; d.refs == 0 && d.pins == 0 && d.file != nil {
+		f = d.cache.detach(d)
+	}
+	d.cache.mu.Unlock()
+
+	closeDirFiles(f)
+}
+
+// pin returns the open file, the directory is reopened when closed in the meantime.
+func (d *Dir) pin() (*os.File, error) {
+	c := d.cache
+
+	c.mu.Lock()
+	if d.file != nil {
+		d.pins++
+		c.busy(d)
+		f := d.file
+		c.mu.Unlock()
+
+		return f, nil
+	}
+	c.mu.Unlock()
+
+	f, err := d.reopen()
+	if err != nil {
+		return nil, err
+	}
+
+	// directory could have been reopened concurrently, the first one wins then
+	var extra *os.File
+
+	c.mu.Lock()
+	if d.file == nil {
+		d.file = f
+		c.open++
+	} else {
+		extra = f
+		c.busy(d)
+	}
+
+	d.pins++
+	f = d.file
+	evicted := c.evict()
+	c.mu.Unlock()
+
+	closeDirFiles(append(evicted, extra)...)
+	return f, nil
+}
+
+// unpin lets the directory be closed, right away when nothing refers to it or the scan is over.
+func (d *Dir) unpin() {
+	var (
+		c     = d.cache
+		files []*os.File
+	)
+
+	c.mu.Lock()
+	if d.pins--; d.pins == 0 {
+		if d.refs <= 0 || c.closed {
+			files = append(files, c.detach(d))
+		} else {
+			d.idle = c.idle.PushFront(d)
+			files = c.evict()
+		}
+	}
+	c.mu.Unlock()
+
+	closeDirFiles(files...)
+}
+
+// reopen opens the directory by its path when it's the root, relative to its parent otherwise.
+func (d *Dir) reopen() (*os.File, error) {
+	var (
+		f   *os.File
+		err error
+	)
+
+	if d.parent == nil {
+		f, err = os.Open(d.name)
+	} else {
+		err = d.parent.use(func(parent *os.File) (err error) {
+			f, err = openDirAt(parent, path.Base(d.name))
+			return err
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	dev, ino, _ := fileID(info)
+
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+
+	if !d.identified {
+		d.dev, d.ino, d.identified = dev, ino, true
+	} else if dev != d.dev || ino != d.ino {
+		f.Close()
+		return nil, &os.PathError{Op: "openat", Path: d.name, Err: ErrDirReplaced}
+	}
+
+	return f, nil
+}
+
+// dirCache keeps at most limit directories of a single scan open, 0 means no limit. The idle ones,
+// neither listed nor used right now, are closed once over the limit, the least recently used first.
+type dirCache struct {
+	mu     sync.Mutex
+	limit  int
+	open   int
+	idle   list.List
+	closed bool
+}
+
+// bounds of the dirCache limit, see dirCacheLimit
+const (
+	minDirCacheLimit = 8
+	maxDirCacheLimit = 4096
+)
+
+func newDirCache(limit int) *dirCache {
+	return &dirCache{limit: limit}
+}
+
+// close closes the idle directories once the scan is over, the pinned ones are closed when unpinned.
+// The directories still referenced, e.g. queued when the scan got cancelled, are closed as well.
+func (c *dirCache) close() {
+	if c == nil {
+		return
+	}
+
+	var files []*os.File
+
+	c.mu.Lock()
+	c.closed = true
+	for c.idle.Len() > 0 {
+		files = append(files, c.detach(c.idle.Back().Value.(*Dir)))
+	}
+	c.mu.Unlock()
+
+	closeDirFiles(files...)
+}
+
+// busy removes the pinned directory from the idle ones.
+func (c *dirCache) busy(d *Dir) {
+	if d.idle != nil {
+		c.idle.Remove(d.idle)
+		d.idle = nil
+	}
+}
+
+// detach takes the file away from the directory, for the caller to close it outside of the lock.
+func (c *dirCache) detach(d *Dir) *os.File {
+	c.busy(d)
+
+	f := d.file
+	d.file = nil
+	c.open--
+
+	return f
+}
+
+func (c *dirCache) evict() []*os.File {
+	var files []*os.File
+	for c.limit > 0 && c.open > c.limit && c.idle.Len() > 0 {
+		files = append(files, c.detach(c.idle.Back().Value.(*Dir)))
+	}
+
+	return files
+}
+
+func closeDirFiles(files ...*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package scanner
+
+import (
+	"os"
+	"path"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const openatSupported = true
+
+func openFileAt(dir *os.File, name string) (*os.File, error) {
+	return openAt(dir, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC)
+}
+
+func openDirAt(dir *os.File, name string) (*os.File, error) {
+	return openAt(dir, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC)
+}
+
+// lstatAt stats the file with fstatat(AT_SYMLINK_NOFOLLOW), which refers to the symlink itself.
+func lstatAt(dir *os.File, name string) (os.FileInfo, error) {
+	var (
+		st  unix.Stat_t
+		err error
+	)
+
+	if controlErr := controlFd(dir, func(fd int) {
+		err = ignoringEINTR(func() error {
+			return unix.Fstatat(fd, name, &st, unix.AT_SYMLINK_NOFOLLOW)
+		})
+	}); controlErr != nil {
+		return nil, controlErr
+	}
+
+	if err != nil {
+		return nil, &os.PathError{Op: "fstatat", Path: path.Join(dir.Name(), name), Err: err}
+	}
+
+	return &statFileInfo{name: name, sys: syscallStat(&st)}, nil
+}
+
+// syscallStat converts the stat to the *syscall.Stat_t os.Lstat gives in Sys.
+func syscallStat(st *unix.Stat_t) syscall.Stat_t {
+	sys := syscall.Stat_t{
+		Ino:  st.Ino,
+		Mode: st.Mode,
+		Uid:  st.Uid,
+		Gid:  st.Gid,
+		Size: st.Size,
+		Atim: syscall.NsecToTimespec(st.Atim.Nano()),
+		Mtim: syscall.NsecToTimespec(st.Mtim.Nano()),
+		Ctim: syscall.NsecToTimespec(st.Ctim.Nano()),
+	}
+
+	// the types of these differ between the architectures
+	setInteger(&sys.Dev, uint64(st.Dev))
+	setInteger(&sys.Rdev, uint64(st.Rdev))
+	setInteger(&sys.Nlink, uint64(st.Nlink))
+	setInteger(&sys.Blksize, uint64(st.Blksize))
+	setInteger(&sys.Blocks, uint64(st.Blocks))
+
+	return sys
+}
+
+// statFileInfo is os.FileInfo out of fstatat, the same as os.Lstat returns.
+type statFileInfo struct {
+	name string
+	sys  syscall.Stat_t
+}
+
+func (i *statFileInfo) Name() string       { return i.name }
+func (i *statFileInfo) Size() int64        { return i.sys.Size }
+func (i *statFileInfo) Mode() os.FileMode  { return unixFileMode(i.sys.Mode) }
+func (i *statFileInfo) ModTime() time.Time { return time.Unix(i.sys.Mtim.Unix()) }
+func (i *statFileInfo) IsDir() bool        { return i.Mode().IsDir() }
+func (i *statFileInfo) Sys() interface{}   { return &i.sys }
+
+// dirCacheLimit is the number of directories a scan keeps open, a quarter of RLIMIT_NOFILE.
+func dirCacheLimit() int {
+	var rlimit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rlimit); err != nil {
+		return maxDirCacheLimit
+	}
+
+	return int(min(max(rlimit.Cur/4, minDirCacheLimit), maxDirCacheLimit))
+}
+
+func openAt(dir *os.File, name string, flags int) (*os.File, error) {
+	var (
+		fd  int
+		err error
+	)
+
+	if controlErr := controlFd(dir, func(dirFd int) {
+		err = ignoringEINTR(func() (err error) {
+			fd, err = unix.Openat(dirFd, name, flags, 0)
+			return err
+		})
+	}); controlErr != nil {
+		return nil, controlErr
+	}
+
+	pathName := path.Join(dir.Name(), name)
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: pathName, Err: err}
+	}
+
+	return os.NewFile(uintptr(fd), pathName), nil
+}
+
+// ignoringEINTR retries the syscall interrupted by a signal.
+func ignoringEINTR(fn func() error) error {
+	for {
+		if err := fn(); err != unix.EINTR {
+			return err
+		}
+	}
+}
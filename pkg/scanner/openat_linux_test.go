@@ -0,0 +1,250 @@
+//go:build linux
+
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// MustMakeDeepDirectory nests the directories relative to each other, so the path of the innermost
+// one may exceed PATH_MAX. It returns the path of the innermost directory.
+func MustMakeDeepDirectory(root string, names ...string) string {
+	fd, err := syscall.Open(root, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, name := range names {
+		if err := syscall.Mkdirat(fd, name, 0755); err != nil {
+			panic(err)
+		}
+
+		childFd, err := syscall.Openat(fd, name, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+		syscall.Close(fd)
+		if err != nil {
+			panic(err)
+		}
+
+		fd = childFd
+	}
+
+	syscall.Close(fd)
+	return path.Join(append([]string{root}, names...)...)
+}
+
+func TestWithOpenat(t *testing.T) {
+	t.Run("When scanning with openat", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-openat")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceFile("level-0-file-1.jpg"),
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceFile("level-1-file-1.1.jpg"),
+				NewWorkspaceDir("level-1-directory-1.1",
+					NewWorkspaceFile("level-2-file-1.1.1.jpg"),
+				),
+			),
+		)).Purge()
+		MustWriteFile(path.Join(dir, "level-0-directory-1/level-1-directory-1.1/level-2-file-1.1.1.jpg"), "content")
+
+		for _, options := range [][]BasicScannerOptionFn{
+			{WithOpenat()},
+			{WithOpenat(), WithLazyStat()},
+			{WithOpenat(), WithGetdents()},
+		} {
+			eager := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))).Scan(context.TODO())))
+			openat := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(append(options, WithOrder(OrderLexical))...))).Scan(context.TODO())))
+
+			Expect(openat).To(HaveLen(len(eager)))
+			var deepest FileItem
+			for i := range eager {
+				if openat[i].FileInfo.Name() == "level-2-file-1.1.1.jpg" {
+					deepest = openat[i]
+				}
+
+				Expect(openat[i].Dir).ToNot(BeNil())
+				Expect(openat[i].Dir.Name()).To(Equal(path.Dir(openat[i].FileInfo.PathName())))
+				Expect(openat[i].FileInfo.PathName()).To(Equal(eager[i].FileInfo.PathName()))
+				Expect(openat[i].FileInfo.Mode()).To(Equal(eager[i].FileInfo.Mode()))
+				Expect(openat[i].FileInfo.Size()).To(Equal(eager[i].FileInfo.Size()))
+			}
+
+			f, err := deepest.Open()
+			Expect(err).ToNot(HaveOccurred())
+			content, err := io.ReadAll(f)
+			f.Close()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("content"))
+		}
+
+		expected := FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir))).Scan(context.TODO())))
+		spilled := FileChanToPathNames(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4), WithQueueSpill(1, ""), WithScannerOptions(WithOpenat()))).Scan(context.TODO())))
+		Expect(SortedPathNames(spilled)).To(Equal(SortedPathNames(expected)))
+	}))
+
+	t.Run("When directory is swapped for a symlink", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-swap")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceFile("level-1-file-1.1.jpg"),
+			),
+			NewWorkspaceDir("outside",
+				NewWorkspaceFile("outside-file.jpg"),
+			),
+		)).Purge()
+
+		swap := func(item FileItem) error {
+			if item.FileInfo != nil && item.FileInfo.Name() == "level-0-directory-1" {
+				Expect(os.Rename(item.FileInfo.PathName(), item.FileInfo.PathName()+"-moved")).To(Succeed())
+				Expect(os.Symlink(path.Join(dir, "outside"), item.FileInfo.PathName())).To(Succeed())
+			}
+
+			return nil
+		}
+
+		var (
+			s       = MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyDepthFirst), WithScannerOptions(WithOrder(OrderLexical), WithOpenat())))
+			errs    []error
+			outside []string
+		)
+
+		Expect(Walk(context.TODO(), s, func(item FileItem) error {
+			if item.Err != nil {
+				errs = append(errs, item.Err)
+			} else if item.FileInfo.Name() == "outside-file.jpg" {
+				outside = append(outside, item.FileInfo.RelPath())
+			}
+
+			return swap(item)
+		})).To(Succeed())
+
+		// the symlink is never followed, the outside directory is scanned under its own path only
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Error()).To(ContainSubstring("level-0-directory-1"))
+		Expect(outside).To(Equal([]string{"outside/outside-file.jpg"}))
+	}))
+
+	t.Run("When path is longer than PATH_MAX", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-too-deep")
+		defer MustNewWorkspace(dir).Purge()
+
+		var names []string
+		for i := 0; i < 24; i++ {
+			names = append(names, strings.Repeat("d", 200))
+		}
+
+		deepest := MustMakeDeepDirectory(dir, names...)
+		Expect(len(deepest)).To(BeNumerically(">", syscall.PathMax))
+
+		files := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOpenat()))).Scan(context.TODO())))
+		Expect(files).To(HaveLen(24))
+		Expect(files).To(HaveDirectories(24))
+		for _, file := range files {
+			Expect(file.Err).ToNot(HaveOccurred())
+		}
+	}))
+
+	t.Run("When there are more directories than open files allowed", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-over-rlimit")
+		defer MustNewWorkspace(dir).Purge()
+
+		for i := 0; i < 300; i++ {
+			Expect(os.MkdirAll(path.Join(dir, fmt.Sprintf("d%d", i), "s", "t"), 0755)).To(Succeed())
+		}
+
+		var limit syscall.Rlimit
+		Expect(syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit)).To(Succeed())
+		defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit)
+		Expect(syscall.Setrlimit(syscall.RLIMIT_NOFILE, &syscall.Rlimit{Cur: 64, Max: limit.Max})).To(Succeed())
+
+		openFiles := func() int {
+			fds, err := os.ReadDir("/proc/self/fd")
+			Expect(err).ToNot(HaveOccurred())
+			return len(fds)
+		}
+		before := openFiles()
+
+		for _, s := range []Scanner{
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4), WithScannerOptions(WithOpenat()))),
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyDepthFirst), WithScannerOptions(WithOpenat()))),
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(StrategyBreadthFirst), WithScannerOptions(WithOpenat()))),
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4), WithScannerOptions(WithOrder(OrderLexical), WithOpenat()))),
+		} {
+			files := FileChanToSlice(MustScan(s.Scan(context.TODO())))
+			Expect(files).To(HaveLen(900))
+			for _, file := range files {
+				Expect(file.Err).ToNot(HaveOccurred())
+			}
+
+			walked := 0
+			Expect(Walk(context.TODO(), s, func(item FileItem) error {
+				Expect(item.Err).ToNot(HaveOccurred())
+				walked++
+				return nil
+			})).To(Succeed())
+			Expect(walked).To(Equal(900))
+
+			// the ordered workers reading ahead close their directories once they're done
+			Eventually(openFiles, time.Second).Should(Equal(before))
+		}
+	}))
+
+	t.Run("When directory of an item is used after the scan", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-to-reopen")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceDir("level-1-directory-1.1",
+					NewWorkspaceFile("level-2-file-1.1.1.jpg"),
+				),
+			),
+		)).Purge()
+
+		var deepest FileItem
+		for _, item := range FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOpenat()))).Scan(context.TODO()))) {
+			if item.FileInfo.Name() == "level-2-file-1.1.1.jpg" {
+				deepest = item
+			}
+		}
+
+		f, err := deepest.Open()
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+
+		// the directory is reopened relative to its parents, which are not the same anymore
+		Expect(os.Rename(path.Join(dir, "level-0-directory-1"), path.Join(dir, "moved"))).To(Succeed())
+		Expect(os.MkdirAll(path.Join(dir, "level-0-directory-1/level-1-directory-1.1"), 0755)).To(Succeed())
+		MustWriteFile(path.Join(dir, "level-0-directory-1/level-1-directory-1.1/level-2-file-1.1.1.jpg"), "content")
+
+		_, err = deepest.Open()
+		Expect(errors.Is(err, ErrDirReplaced)).To(BeTrue())
+	}))
+
+	t.Run("When openat can't be used", ScannerTest(func(t *testing.T) {
+		_, err := NewBasicScanner(WithFS(fstest.MapFS{}), WithOpenat())
+		Expect(err).To(Equal(ErrOpenatNotSupported))
+
+		_, err = NewRecursiveScanner(WithFollowSymlinks(), WithScannerOptions(WithOpenat()))
+		Expect(err).To(Equal(ErrOpenatNotSupported))
+	}))
+
+	t.Run("When openat is built", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").Openat().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithOpenat()))),
+		))
+	}))
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package scanner
+
+import "os"
+
+const openatSupported = false
+
+func openFileAt(dir *os.File, name string) (*os.File, error) {
+	return nil, ErrOpenatNotSupported
+}
+
+func openDirAt(dir *os.File, name string) (*os.File, error) {
+	return nil, ErrOpenatNotSupported
+}
+
+func lstatAt(dir *os.File, name string) (os.FileInfo, error) {
+	return nil, ErrOpenatNotSupported
+}
+
+func dirCacheLimit() int {
+	return maxDirCacheLimit
+}
@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+type Order int8
+
+const (
+	OrderNone Order = iota
+	OrderLexical
+	OrderNatural
+	OrderSize
+	OrderModTime
+)
+
+func (o Order) sortFileInfos(infos []os.FileInfo) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		return o.compareFileInfos(infos[i], infos[j]) < 0
+	})
+}
+
+func (o Order) sortDirectories(directories []string) {
+	if o != OrderLexical && o != OrderNatural {
+		return
+	}
+
+	sort.SliceStable(directories, func(i, j int) bool {
+		return o.comparePaths(directories[i], directories[j]) < 0
+	})
+}
+
+// compareFileInfos compares the siblings, so the items from the same directory.
+func (o Order) compareFileInfos(a, b os.FileInfo) int {
+	switch o {
+	case OrderNatural:
+		return compareNatural(a.Name(), b.Name())
+	case OrderSize:
+		if c := compareInt64(a.Size(), b.Size()); c != 0 {
+			return c
+		}
+	case OrderModTime:
+		if c := compareInt64(a.ModTime().UnixNano(), b.ModTime().UnixNano()); c != 0 {
+			return c
+		}
+	}
+
+	return strings.Compare(a.Name(), b.Name())
+}
+
+// compareItems compares the items coming from different scanners. Errors always go first.
+func (o Order) compareItems(a, b FileItem) int {
+	switch {
+	case a.FileInfo == nil && b.FileInfo == nil:
+		return 0
+	case a.FileInfo == nil:
+		return -1
+	case b.FileInfo == nil:
+		return 1
+	}
+
+	switch o {
+	case OrderSize:
+		if c := compareInt64(a.FileInfo.Size(), b.FileInfo.Size()); c != 0 {
+			return c
+		}
+	case OrderModTime:
+		if c := compareInt64(a.FileInfo.ModTime().UnixNano(), b.FileInfo.ModTime().UnixNano()); c != 0 {
+			return c
+		}
+	}
+
+	return o.comparePaths(a.FileInfo.PathName(), b.FileInfo.PathName())
+}
+
+// comparePaths compares the paths component by component, which is the order the depth-first
+// traversal visits them, e.g. "a/b" goes before "a-b".
+func (o Order) comparePaths(a, b string) int {
+	compare := strings.Compare
+	if o == OrderNatural {
+		compare = compareNatural
+	}
+
+	for a != "" && b != "" {
+		var ca, cb string
+		ca, a = splitFirstComponent(a)
+		cb, b = splitFirstComponent(b)
+
+		if c := compare(ca, cb); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt64(int64(len(a)), int64(len(b)))
+}
+
+func splitFirstComponent(p string) (string, string) {
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		return p[:i], p[i+1:]
+	}
+
+	return p, ""
+}
+
+// compareNatural compares the strings treating the runs of digits as numbers, so "file2" goes
+// before "file10".
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var na, nb string
+			na, a = splitDigits(a)
+			nb, b = splitDigits(b)
+
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if c := compareInt64(int64(len(ta)), int64(len(tb))); c != 0 {
+				return c
+			}
+
+			if c := strings.Compare(ta, tb); c != 0 {
+				return c
+			}
+
+			// equal numbers, less leading zeros first
+			if c := compareInt64(int64(len(na)), int64(len(nb))); c != 0 {
+				return c
+			}
+
+			continue
+		}
+
+		if a[0] != b[0] {
+			return compareInt64(int64(a[0]), int64(b[0]))
+		}
+
+		a, b = a[1:], b[1:]
+	}
+
+	return compareInt64(int64(len(a)), int64(len(b)))
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
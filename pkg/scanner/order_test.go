@@ -0,0 +1,146 @@
+package scanner_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestBasicScannerOrder(t *testing.T) {
+	dir := NewDirectoryPath("directory-with-numbered-files")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("file-10.jpg"),
+		NewWorkspaceFile("file-2.jpg"),
+		NewWorkspaceFile("file-1.jpg"),
+		NewWorkspaceFile("file-02.jpg"),
+		NewWorkspaceFile("file-3.jpg"),
+	)).Purge()
+
+	MustWriteFile(path.Join(dir, "file-2.jpg"), "lorem ipsum")
+	MustWriteFile(path.Join(dir, "file-10.jpg"), "lorem")
+
+	var testCases = []struct {
+		Name     string
+		Order    Order
+		Expected []string
+	}{
+		{"When lexical order is set", OrderLexical, []string{"file-02.jpg", "file-1.jpg", "file-10.jpg", "file-2.jpg", "file-3.jpg"}},
+		{"When natural order is set", OrderNatural, []string{"file-1.jpg", "file-2.jpg", "file-02.jpg", "file-3.jpg", "file-10.jpg"}},
+		{"When size order is set", OrderSize, []string{"file-02.jpg", "file-1.jpg", "file-3.jpg", "file-10.jpg", "file-2.jpg"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, ScannerTest(func(t *testing.T) {
+			var expected []string
+			for _, name := range tc.Expected {
+				expected = append(expected, path.Join(dir, name))
+			}
+
+			fileChan, err := MustScanner(NewBasicScanner(WithDir(dir), WithOrder(tc.Order), WithBulkSize(2))).Scan(context.TODO())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileChan).To(WithTransform(FileChanToPathNames, Equal(expected)))
+		}))
+	}
+}
+
+func TestRecursiveScannerOrder(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-sort")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("b.jpg"),
+		NewWorkspaceDir("a",
+			NewWorkspaceFiles("a", 10)...,
+		),
+		NewWorkspaceDir("a-b",
+			NewWorkspaceDir("c",
+				NewWorkspaceFiles("c", 10)...,
+			),
+			NewWorkspaceDir("b",
+				NewWorkspaceFiles("b", 10)...,
+			),
+		),
+		NewWorkspaceDir("c",
+			NewWorkspaceDir("d",
+				NewWorkspaceDir("e",
+					NewWorkspaceFiles("e", 10)...,
+				),
+			),
+		),
+	)).Purge()
+
+	t.Run("When lexical order is set, then the order is the same as filepath.Walk one", ScannerTest(func(t *testing.T) {
+		var expected []string
+		Expect(filepath.Walk(dir, func(pathName string, _ os.FileInfo, err error) error {
+			if pathName != dir {
+				expected = append(expected, pathName)
+			}
+
+			return err
+		})).To(Succeed())
+
+		for i := 0; i < 10; i++ {
+			fileChan, err := MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithWorkers(4),
+				WithScannerOptions(WithOrder(OrderLexical)),
+			)).Scan(context.TODO())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileChan).To(WithTransform(FileChanToPathNames, Equal(expected)))
+		}
+	}))
+
+	t.Run("When depth is limited", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithMinDepth(2),
+			WithMaxDepth(2),
+			WithScannerOptions(WithOrder(OrderLexical)),
+		)).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(13),
+			HaveDirectories(3),
+			HaveRegularFiles(10),
+		)))
+	}))
+}
+
+func TestOrderedMultiScanner(t *testing.T) {
+	firstDir := NewDirectoryPath("first-directory")
+	defer MustNewWorkspace(firstDir, WithItems(NewWorkspaceFiles("file", 5)...)).Purge()
+
+	secondDir := NewDirectoryPath("second-directory")
+	defer MustNewWorkspace(secondDir, WithItems(NewWorkspaceFiles("file", 5)...)).Purge()
+
+	t.Run("When both scanners are ordered", ScannerTest(func(t *testing.T) {
+		var expected []string
+		for _, d := range []string{firstDir, secondDir} {
+			for i := 0; i < 5; i++ {
+				expected = append(expected, path.Join(d, "file-"+string(rune('0'+i))))
+			}
+		}
+
+		fileChan, err := NewOrderedMultiScanner(
+			OrderLexical,
+			MustScanner(NewBasicScanner(WithDir(secondDir), WithOrder(OrderLexical))),
+			MustScanner(NewBasicScanner(WithDir(firstDir), WithOrder(OrderLexical))),
+		).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToPathNames, Equal(expected)))
+	}))
+
+	t.Run("When one of the scanners fails", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewOrderedMultiScanner(OrderLexical, &FailingScanner{}).Scan(context.TODO())
+
+		Expect(err).To(HaveOccurred())
+		Expect(fileChan).To(BeNil())
+	}))
+}
@@ -0,0 +1,271 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+type Strategy int8
+
+const (
+	// StrategyParallel emits the items as soon as any of the workers finds them
+	StrategyParallel Strategy = iota
+	// StrategyDepthFirst emits the whole subtree of a directory before moving to its next sibling
+	StrategyDepthFirst
+	// StrategyBreadthFirst emits all the items of the given depth before going any deeper
+	StrategyBreadthFirst
+)
+
+// listing is the content of a single directory, read by one of the workers ahead of time and
+// consumed by the emitter in the deterministic order.
+type listing struct {
+	dir   directory
+	items []FileItem
+	done  chan struct{}
+}
+
+func newListing(dir directory) *listing {
+	return &listing{dir: dir, done: make(chan struct{})}
+}
+
+// pendingListings holds the listings waiting to be read. Workers read them in the exact order the
+// emitter consumes them: most recently discovered first for the depth-first traversal (lifo) and
+// the least recently discovered first for the breadth-first one.
+type pendingListings struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	listings []*listing
+	lifo     bool
+	closed   bool
+}
+
+func newPendingListings(lifo bool) *pendingListings {
+	pl := &pendingListings{lifo: lifo}
+	pl.cond = sync.NewCond(&pl.mu)
+	return pl
+}
+
+// push adds the listings in a way the first one is popped first.
+func (pl *pendingListings) push(listings ...*listing) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if !pl.lifo {
+		pl.listings = append(pl.listings, listings...)
+	} else {
+		for i := len(listings) - 1; i >= 0; i-- {
+			pl.listings = append(pl.listings, listings[i])
+		}
+	}
+
+	pl.cond.Broadcast()
+}
+
+func (pl *pendingListings) pop() (*listing, bool) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	for len(pl.listings) == 0 && !pl.closed {
+		pl.cond.Wait()
+	}
+
+	if pl.closed {
+		return nil, false
+	}
+
+	var l *listing
+	if pl.lifo {
+		l = pl.listings[len(pl.listings)-1]
+		pl.listings[len(pl.listings)-1] = nil
+		pl.listings = pl.listings[:len(pl.listings)-1]
+	} else {
+		l = pl.listings[0]
+		pl.listings[0] = nil
+		pl.listings = pl.listings[1:]
+	}
+
+	return l, true
+}
+
+func (pl *pendingListings) close() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	pl.closed = true
+	pl.cond.Broadcast()
+}
+
+// orderedScan reads the directories in parallel, but emits the items in the deterministic order.
+// Workers never read more than readAhead listings the emitter has not consumed yet, so the memory
+// is bounded by the directories in flight.
+type orderedScan struct {
+	*RecursiveScanner
+	pending   *pendingListings
+	readAhead chan struct{}
+	emit      emitFn
+}
+
+// scanOrdered returns once the items are emitted, the workers exit on their own afterwards.
+func (s *RecursiveScanner) scanOrdered(ctx context.Context, emit emitFn) {
+	var (
+		o = &orderedScan{
+			RecursiveScanner: s,
+			pending:          newPendingListings(s.strategy != StrategyBreadthFirst),
+			readAhead:        make(chan struct{}, 2*s.workers),
+			emit:             emit,
+		}
+		directories = append([]string(nil), s.directories...)
+		roots       []*listing
+	)
+
+	defer o.pending.close()
+
+	// workers still reading ahead close their directories right away afterwards
+	dirs := s.newDirCache()
+	defer dirs.close()
+
+	for i := uint(0); i < s.workers; i++ {
+		go o.read(ctx)
+	}
+
+	s.template.order.sortDirectories(directories)
+	for _, d := range directories {
+		roots = append(roots, newListing(s.rootDirectory(d, dirs)))
+	}
+
+	o.pending.push(roots...)
+
+	if s.strategy == StrategyBreadthFirst {
+		o.emitBreadthFirst(ctx, roots)
+		return
+	}
+
+	for _, root := range roots {
+		if !o.emitDepthFirst(ctx, root) {
+			return
+		}
+	}
+}
+
+func (o *orderedScan) read(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case o.readAhead <- struct{}{}:
+		}
+
+		l, ok := o.pending.pop()
+		if !ok {
+			return
+		}
+
+		l.items = o.readListing(ctx, &l.dir)
+		close(l.done)
+	}
+}
+
+// consume waits for the listing to be read and schedules reading of its subdirectories.
+func (o *orderedScan) consume(ctx context.Context, l *listing) (map[int]*listing, []*listing, bool) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, false
+	case <-l.done:
+	}
+
+	var (
+		depth       = l.dir.depth + 1
+		subListings = make(map[int]*listing)
+		toRead      []*listing
+	)
+
+	for i := range l.items {
+		if subDir, ok := o.subDirectory(&l.dir, &l.items[i], depth); ok {
+			if o.template.dirEvents {
+				subDir.node = l.dir.node.newChild()
+			}
+
+			subListings[i] = newListing(subDir)
+			toRead = append(toRead, subListings[i])
+		}
+	}
+
+	// subdirectories must be pending before the slot is released, otherwise a worker could use it to
+	// read a listing needed later and the emitter would wait forever
+	o.pending.push(toRead...)
+	<-o.readAhead
+
+	return subListings, toRead, true
+}
+
+func (o *orderedScan) emitDepthFirst(ctx context.Context, l *listing) bool {
+	subListings, _, ok := o.consume(ctx, l)
+	if !ok {
+		return false
+	}
+
+	defer l.dir.close()
+
+	depth := l.dir.depth + 1
+
+	// listing starts with DirStart and ends with DirEnd, so the events are in place already
+	for i, item := range l.items {
+		if (depth >= o.minDepth || item.Kind != KindEntry) && o.emit(item) == walkStop {
+			return false
+		}
+
+		if subListing, ok := subListings[i]; ok {
+			if !o.emitDepthFirst(ctx, subListing) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (o *orderedScan) emitBreadthFirst(ctx context.Context, queue []*listing) {
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+
+		_, toRead, ok := o.consume(ctx, l)
+		if !ok {
+			return
+		}
+
+		var dirEnd FileItem
+		for _, item := range l.items {
+			switch {
+			case item.Kind == KindDirEnd:
+				// directory is done when all of its subdirectories are done, see dirNode
+				dirEnd = item
+			case l.dir.depth+1 >= o.minDepth || item.Kind != KindEntry:
+				if o.emit(item) == walkStop {
+					return
+				}
+			}
+		}
+
+		l.dir.close()
+
+		if o.template.dirEvents {
+			for _, item := range l.dir.node.done(dirEnd) {
+				if o.emit(item) == walkStop {
+					return
+				}
+			}
+		}
+
+		queue = append(queue, toRead...)
+	}
+}
+
+func (s *RecursiveScanner) readListing(ctx context.Context, dir *directory) []FileItem {
+	var items []FileItem
+	for item := range s.directoryItems(ctx, dir) {
+		items = append(items, item)
+	}
+
+	return items
+}
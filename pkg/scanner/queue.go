@@ -0,0 +1,268 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+// directoryQueue holds the directories waiting for the worker.
+type directoryQueue interface {
+	// push returns false when the queue is full, the directory has to be scanned by the caller then
+	push(dir directory) (bool, error)
+	pop() (directory, bool, error)
+	len() int
+	close() error
+}
+
+// memoryQueue keeps at most limit directories in memory, 0 means no limit.
+type memoryQueue struct {
+	directories []directory
+	limit       uint
+}
+
+func newMemoryQueue(limit uint) *memoryQueue {
+	return &memoryQueue{limit: limit}
+}
+
+func (q *memoryQueue) push(dir directory) (bool, error) {
+	if q.limit > 0 && uint(len(q.directories)) >= q.limit {
+		return false, nil
+	}
+
+	q.directories = append(q.directories, dir)
+	return true, nil
+}
+
+func (q *memoryQueue) pop() (directory, bool, error) {
+	if len(q.directories) == 0 {
+		return directory{}, false, nil
+	}
+
+	dir := q.directories[0]
+	q.directories[0] = directory{}
+	q.directories = q.directories[1:]
+
+	return dir, true, nil
+}
+
+func (q *memoryQueue) len() int {
+	return len(q.directories)
+}
+
+func (q *memoryQueue) close() error {
+	q.directories = nil
+	return nil
+}
+
+// spillingQueue keeps at most limit directories in memory and spills the rest to the temporary
+// file, which is read back once the memory gets empty. Only the plain fields are spilled, the
+// references (tracked for the symlinks, directory events, gitignore & openat mode only) point at
+// the live values and stay in memory, so the memory is not bounded in those modes.
+type spillingQueue struct {
+	memory  *memoryQueue
+	tempDir string
+
+	file       *os.File
+	writer     *bufio.Writer
+	reader     *bufio.Reader
+	readOffset int64
+	spilled    int
+
+	refs   map[uint64]directoryRefs
+	nextID uint64
+}
+
+type directoryRefs struct {
+	parent    *directory
+	info      os.FileInfo
+	node      *dirNode
+	parentDir *Dir
+	ignore    *ignoreRules
+}
+
+func newSpillingQueue(limit uint, tempDir string) *spillingQueue {
+	if limit == 0 {
+		limit = 1
+	}
+
+	return &spillingQueue{
+		memory:  newMemoryQueue(limit),
+		tempDir: tempDir,
+		refs:    make(map[uint64]directoryRefs),
+	}
+}
+
+func (q *spillingQueue) push(dir directory) (bool, error) {
+	// keep the order, nothing goes to memory until the spilled directories are read back
+	if q.spilled == 0 {
+		if ok, _ := q.memory.push(dir); ok {
+			return true, nil
+		}
+	}
+
+	if q.file == nil {
+		f, err := os.CreateTemp(q.tempDir, "scanner-queue-")
+		if err != nil {
+			return false, err
+		}
+
+		q.file = f
+		q.writer = bufio.NewWriter(io.NewOffsetWriter(f, 0))
+	}
+
+	if err := q.write(dir); err != nil {
+		return false, err
+	}
+
+	q.spilled++
+	return true, nil
+}
+
+func (q *spillingQueue) pop() (directory, bool, error) {
+	if q.memory.len() == 0 && q.spilled > 0 {
+		if err := q.readBack(); err != nil {
+			return directory{}, false, err
+		}
+	}
+
+	return q.memory.pop()
+}
+
+func (q *spillingQueue) len() int {
+	return q.memory.len() + q.spilled
+}
+
+func (q *spillingQueue) close() error {
+	q.memory.close()
+	q.refs = nil
+
+	if q.file == nil {
+		return nil
+	}
+
+	q.file.Close()
+	return os.Remove(q.file.Name())
+}
+
+// readBack moves the spilled directories to the memory, as many as it fits.
+func (q *spillingQueue) readBack() error {
+	// reader never gets past the flushed records, so it never reads the record partially
+	if err := q.writer.Flush(); err != nil {
+		return err
+	}
+
+	// reader is created every time, buffered one would keep returning EOF for the records written
+	// after it has reached the end of the file
+	section := io.NewSectionReader(q.file, q.readOffset, math.MaxInt64-q.readOffset)
+	if q.reader == nil {
+		q.reader = bufio.NewReader(section)
+	} else {
+		q.reader.Reset(section)
+	}
+
+	for q.spilled > 0 && uint(q.memory.len()) < q.memory.limit {
+		dir, err := q.read()
+		if err != nil {
+			return err
+		}
+
+		q.memory.push(dir)
+		q.spilled--
+	}
+
+	// everything is read back, start over with the empty file
+	if q.spilled == 0 {
+		if err := q.file.Truncate(0); err != nil {
+			return err
+		}
+
+		q.readOffset = 0
+		q.writer.Reset(io.NewOffsetWriter(q.file, 0))
+		return nil
+	}
+
+	read, err := section.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	q.readOffset += read - int64(q.reader.Buffered())
+	return nil
+}
+
+func (q *spillingQueue) write(dir directory) error {
+	var id uint64
+	if dir.parent != nil || dir.info != nil || dir.node != nil || dir.parentDir != nil || dir.ignore != nil {
+		q.nextID++
+		id = q.nextID
+		q.refs[id] = directoryRefs{dir.parent, dir.info, dir.node, dir.parentDir, dir.ignore}
+	}
+
+	var hasDevice uint64
+	if dir.hasDevice {
+		hasDevice = 1
+	}
+
+	buf := make([]byte, 0, len(dir.path)+len(dir.root)+len(dir.relPath)+7*binary.MaxVarintLen64)
+	for _, s := range []string{dir.path, dir.root, dir.relPath} {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(dir.depth))
+	buf = binary.AppendUvarint(buf, dir.device)
+	buf = binary.AppendUvarint(buf, hasDevice)
+	buf = binary.AppendUvarint(buf, id)
+
+	_, err := q.writer.Write(buf)
+	return err
+}
+
+func (q *spillingQueue) read() (directory, error) {
+	// path, root & relPath are followed by depth, device, hasDevice & id
+	var (
+		strs   [3]string
+		fields [4]uint64
+	)
+
+	for i := range strs {
+		n, err := binary.ReadUvarint(q.reader)
+		if err != nil {
+			return directory{}, err
+		}
+
+		b := make([]byte, n)
+		if _, err := io.ReadFull(q.reader, b); err != nil {
+			return directory{}, err
+		}
+
+		strs[i] = string(b)
+	}
+
+	for i := range fields {
+		var err error
+		if fields[i], err = binary.ReadUvarint(q.reader); err != nil {
+			return directory{}, err
+		}
+	}
+
+	dir := directory{
+		path:      strs[0],
+		root:      strs[1],
+		relPath:   strs[2],
+		depth:     uint(fields[0]),
+		device:    fields[1],
+		hasDevice: fields[2] == 1,
+	}
+
+	if id := fields[3]; id > 0 {
+		refs := q.refs[id]
+		delete(q.refs, id)
+		dir.parent, dir.info, dir.node, dir.parentDir, dir.ignore = refs.parent, refs.info, refs.node, refs.parentDir, refs.ignore
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,83 @@
+package scanner_test
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestRecursiveScannerQueue(t *testing.T) {
+	dir := NewDirectoryPath("directory-with-many-subdirectories")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceDir("directory-1",
+			NewWorkspaceDir("directory-1.1", NewWorkspaceFiles("file-1.1", 3)...),
+			NewWorkspaceDir("directory-1.2", NewWorkspaceFiles("file-1.2", 3)...),
+			NewWorkspaceDir("directory-1.3",
+				NewWorkspaceDir("directory-1.3.1", NewWorkspaceFiles("file-1.3.1", 3)...),
+				NewWorkspaceDir("directory-1.3.2"),
+			),
+		),
+		NewWorkspaceDir("directory-2",
+			NewWorkspaceDir("directory-2.1"),
+			NewWorkspaceDir("directory-2.2", NewWorkspaceFiles("file-2.2", 3)...),
+		),
+		NewWorkspaceDir("directory-3", NewWorkspaceFiles("file-3", 3)...),
+	)).Purge()
+
+	tempDir := NewDirectoryPath("directory-for-spilled-queue")
+	defer MustNewWorkspace(tempDir).Purge()
+
+	scan := func(options ...RecursiveScannerOptionFn) []string {
+		fileChan, err := MustScanner(NewRecursiveScanner(append([]RecursiveScannerOptionFn{
+			WithDirectories(dir),
+			WithWorkers(2),
+		}, options...)...)).Scan(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+
+		pathNames := FileChanToPathNames(fileChan)
+		sort.Strings(pathNames)
+		return pathNames
+	}
+
+	t.Run("When queue is limited", ScannerTest(func(t *testing.T) {
+		Expect(scan(WithQueueLimit(1))).To(And(HaveLen(25), Equal(scan())))
+	}))
+
+	t.Run("When queue is spilled to the disk", ScannerTest(func(t *testing.T) {
+		Expect(scan(WithQueueSpill(2, tempDir))).To(And(HaveLen(25), Equal(scan())))
+
+		files, err := os.ReadDir(tempDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	}))
+
+	t.Run("When queue is limited and directory events are emitted", ScannerTest(func(t *testing.T) {
+		for _, option := range []RecursiveScannerOptionFn{WithQueueLimit(1), WithQueueSpill(1, tempDir)} {
+			fileChan, err := MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithWorkers(2),
+				WithScannerOptions(WithDirEvents()),
+				option,
+			)).Scan(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			var starts, ends int
+			for _, file := range FileChanToSlice(fileChan) {
+				switch file.Kind {
+				case KindDirStart:
+					starts++
+				case KindDirEnd:
+					ends++
+				}
+			}
+
+			// every directory, the root included
+			Expect(starts).To(Equal(11))
+			Expect(ends).To(Equal(11))
+		}
+	}))
+}
@@ -2,8 +2,12 @@ package scanner
 
 import (
 	"context"
+	"iter"
 	"os"
 	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type RecursiveScannerOptionFn func(s *RecursiveScanner) error
@@ -16,15 +20,6 @@ func WithDirectories(directories ...string) RecursiveScannerOptionFn {
 		)
 
 		for _, d := range directories {
-			info, err := os.Stat(d)
-			if err != nil {
-				return err
-			}
-
-			if !info.IsDir() {
-				return ErrNotDirectory
-			}
-
 			if _, exists := uniqueDirectoriesMap[d]; exists {
 				continue
 			}
@@ -42,13 +37,220 @@ func WithDirectories(directories ...string) RecursiveScannerOptionFn {
 func WithWorkers(workers uint) RecursiveScannerOptionFn {
 	return func(s *RecursiveScanner) error {
 		s.workers = workers
+		s.adaptive = false
+		return nil
+	}
+}
+
+func WithMaxDepth(depth uint) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.maxDepth = depth
+		return nil
+	}
+}
+
+func WithMinDepth(depth uint) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.minDepth = depth
+		return nil
+	}
+}
+
+func WithFollowSymlinks() RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.followSymlinks = true
+		return nil
+	}
+}
+
+func WithSameFilesystem() RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.sameFilesystem = true
+		return nil
+	}
+}
+
+func WithPrune(filter Filter) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		if s.prune != nil {
+			filter = OrFilter(s.prune, filter)
+		}
+
+		s.prune = filter
+		return nil
+	}
+}
+
+func WithStrategy(strategy Strategy) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.strategy = strategy
+		return nil
+	}
+}
+
+func WithScannerOptions(options ...BasicScannerOptionFn) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.scannerOptions = append(s.scannerOptions, options...)
+		return nil
+	}
+}
+
+// WithQueueLimit keeps at most limit directories waiting in the queue. When the queue is full,
+// the worker scans the subdirectories it has found by itself, before taking any new work. They wait
+// on the stack of the worker meanwhile, which grows with the depth times the fan-out of the tree,
+// so the memory is not capped at the limit, it's the limit plus the stacks of the workers.
+func WithQueueLimit(limit uint) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.queueLimit = limit
+		s.queueSpill = false
+		return nil
+	}
+}
+
+// WithQueueSpill keeps at most limit directories waiting in the memory, the rest goes to the
+// temporary file in tempDir (os.TempDir() when empty). The queue never gets full, so the workers
+// don't stack the subdirectories. Only the paths are spilled though, the state of the followed
+// symlinks, directory events, gitignore & openat stays in memory for every spilled directory.
+func WithQueueSpill(limit uint, tempDir string) RecursiveScannerOptionFn {
+	return func(s *RecursiveScanner) error {
+		s.queueLimit = limit
+		s.queueSpill = true
+		s.queueTempDir = tempDir
 		return nil
 	}
 }
 
 type RecursiveScanner struct {
-	directories []string
-	workers     uint
+	directories    []string
+	workers        uint
+	maxDepth       uint
+	minDepth       uint
+	followSymlinks bool
+	sameFilesystem bool
+	prune          Filter
+	strategy       Strategy
+	scannerOptions []BasicScannerOptionFn
+	queueLimit     uint
+	queueSpill     bool
+	queueTempDir   string
+	gitignore      bool
+	gitExcludes    []ignorePattern
+
+	// workers is the upper bound of the adaptive pool & minWorkers the lower one, see WithAdaptiveWorkers
+	adaptive   bool
+	minWorkers uint
+
+	// currentWorkers holds the number of the latest scan in the upper half & its workers in the lower
+	// one, scans counts the scans started, see setWorkers
+	currentWorkers atomic.Uint64
+	scans          atomic.Uint32
+
+	// template is the BasicScanner built out of the scannerOptions, every directory is scanned alike
+	template *BasicScanner
+}
+
+// directory is a single unit of work for the workers pool. Depth of the root directories is 0,
+// so the items found directly inside of them have depth 1.
+type directory struct {
+	path    string
+	root    string
+	relPath string
+	depth   uint
+
+	// parent & info are tracked only when symlinks are followed, to detect the cycles
+	parent *directory
+	info   os.FileInfo
+
+	// device of the root directory, tracked only when scanning is limited to the single filesystem
+	device    uint64
+	hasDevice bool
+
+	// node is tracked only when directory events are emitted
+	node *dirNode
+
+	// parentDir is the opened parent directory, tracked only in the openat mode. It's retained until
+	// the directory is opened, see directoryItems. Roots have the dirs of the scan instead.
+	parentDir *Dir
+	dirs      *dirCache
+
+	// opened is the directory itself once listed, released when its items are emitted, see close
+	opened *Dir
+
+	// ignore are the rules the items are skipped by, tracked only with WithGitignore
+	ignore *ignoreRules
+}
+
+// dirNode counts the directories of the subtree, which are not scanned yet. Directory itself is
+// counted as well, so the subtree is fully scanned when the counter drops to 0.
+type dirNode struct {
+	parent  *dirNode
+	pending int
+	dirEnd  FileItem
+}
+
+func (n *dirNode) newChild() *dirNode {
+	n.pending++
+	return &dirNode{parent: n, pending: 1}
+}
+
+// done marks the directory as scanned and returns the DirEnd items of all the directories, which
+// subtrees got fully scanned because of that, the innermost first.
+func (n *dirNode) done(dirEnd FileItem) []FileItem {
+	var items []FileItem
+
+	n.dirEnd = dirEnd
+	for ; n != nil; n = n.parent {
+		if n.pending--; n.pending > 0 {
+			break
+		}
+
+		// unreadable directory has neither DirStart nor DirEnd
+		if n.dirEnd.Kind == KindDirEnd {
+			items = append(items, n.dirEnd)
+		}
+	}
+
+	return items
+}
+
+// scannedDirectory is reported by the worker once it is done with the directory. Scheduler replies
+// with the subdirectories which did not fit into the queue, the worker has to scan them itself.
+type scannedDirectory struct {
+	dir          directory
+	subDirs      []directory
+	dirEnd       FileItem
+	stats        directoryStats
+	hasMore      bool
+	overflowChan chan []directory
+}
+
+func (d *directory) isDescendantOf(info os.FileInfo) bool {
+	for ; d != nil; d = d.parent {
+		if d.info != nil && sameFile(d.info, info) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameFile is os.SameFile, which sees through the File wrapper. os.SameFile itself only ever matches
+// the FileInfo of os.Stat & os.Lstat, while the scanned ones are wrapped.
+func sameFile(a, b os.FileInfo) bool {
+	if aDev, aIno, ok := fileID(a); ok {
+		bDev, bIno, ok := fileID(b)
+		return ok && aDev == bDev && aIno == bIno
+	}
+
+	return os.SameFile(unwrapFileInfo(a), unwrapFileInfo(b))
+}
+
+func unwrapFileInfo(info os.FileInfo) os.FileInfo {
+	if f, ok := info.(File); ok {
+		return f.FileInfo
+	}
+
+	return info
 }
 
 func NewRecursiveScanner(options ...RecursiveScannerOptionFn) (*RecursiveScanner, error) {
@@ -62,6 +264,34 @@ func NewRecursiveScanner(options ...RecursiveScannerOptionFn) (*RecursiveScanner
 		}
 	}
 
+	if s.maxDepth > 0 && s.minDepth > s.maxDepth {
+		return nil, ErrInvalidDepthRange
+	}
+
+	template, err := NewBasicScanner(s.scannerOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.template = template
+
+	// following the symlinks is exactly what the openat mode prevents
+	if s.template.openat && s.followSymlinks {
+		return nil, ErrOpenatNotSupported
+	}
+
+	// directories are looked up in the filesystem of the template, see WithFS
+	for _, d := range s.directories {
+		info, err := s.template.fsys.Stat(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			return nil, ErrNotDirectory
+		}
+	}
+
 	return &s, nil
 }
 
@@ -72,86 +302,430 @@ func (s *RecursiveScanner) Scan(ctx context.Context) (FileItemChan, error) {
 		return outFileItemChan, nil
 	}
 
+	go func() {
+		// output is closed exactly once, when no worker can send to it anymore
+		defer close(outFileItemChan)
+
+		s.scan(ctx, sendEmit(ctx, outFileItemChan))
+	}()
+
+	return outFileItemChan, nil
+}
+
+// ScanBatches collects the items of all the workers into the same batches.
+func (s *RecursiveScanner) ScanBatches(ctx context.Context, size int) (FileItemBatchChan, error) {
+	batchChan := make(FileItemBatchChan)
+	if len(s.directories) == 0 {
+		defer close(batchChan)
+		return batchChan, nil
+	}
+
+	go func() {
+		defer close(batchChan)
+
+		b := newBatcher(ctx, batchChan, size)
+		s.scan(ctx, b.emit)
+		b.flush()
+	}()
+
+	return batchChan, nil
+}
+
+func (s *RecursiveScanner) scan(ctx context.Context, emit emitFn) {
+	// sorted listings are pointless when emitted in parallel, so depth-first is the default then
+	if s.strategy != StrategyParallel || s.template.order != OrderNone {
+		s.scanOrdered(ctx, emit)
+		return
+	}
+
+	s.scanParallel(ctx, emit)
+}
+
+// scanParallel scans the directories with the workers pool and returns once all of them are done.
+// Items are emitted by the workers concurrently.
+func (s *RecursiveScanner) scanParallel(ctx context.Context, emit emitFn) {
+	// scheduler cancels the workers when it can't go on, e.g. the spilled queue can't be read back
+	ctx, cancel := context.WithCancel(ctx)
+
 	var (
-		directoriesToScanQueue []string
-		workers                = make(map[string]interface{})
-		finishedScanningChan   = make(chan string)
-		scheduleScanningChan   = make(chan string, s.workers)
-		doneChan               = make(chan struct{})
+		// roots are given by the caller, the queue limit applies to the directories found on the way
+		roots                []directory
+		queue                = s.newQueue()
+		workers              uint
+		workersLimit         = s.initialWorkers()
+		workersWg            sync.WaitGroup
+		finishedScanningChan = make(chan scannedDirectory)
+		controller           *workersController
 	)
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case dir := <-scheduleScanningChan:
-				// spawn scanning immediately if possible
-				if uint(len(workers)) < s.workers {
-					workers[dir] = dir
-					go doScan(ctx, dir, outFileItemChan, finishedScanningChan, scheduleScanningChan)
-					continue
+	if s.adaptive {
+		controller = newWorkersController(s.minWorkers, s.workers, workersLimit)
+	}
+
+	scan := s.startScan()
+	s.setWorkers(scan, workersLimit)
+
+	// directories are closed once the workers are gone
+	dirs := s.newDirCache()
+	defer dirs.close()
+
+	defer workersWg.Wait()
+	defer cancel()
+	defer queue.close()
+
+	for _, d := range s.directories {
+		roots = append(roots, s.rootDirectory(d, dirs))
+	}
+
+	for {
+		// spawn as many workers as the queue & the limit allow
+		for workers < workersLimit && len(roots)+queue.len() > 0 {
+			var dir directory
+			if len(roots) > 0 {
+				dir, roots = roots[0], roots[1:]
+			} else {
+				var err error
+				if dir, _, err = queue.pop(); err != nil {
+					emit(FileItem{Err: err})
+					return
+				}
+			}
+
+			workers++
+			workersWg.Add(1)
+			go func() {
+				defer workersWg.Done()
+				s.doScan(ctx, dir, emit, finishedScanningChan)
+			}()
+		}
+
+		// no workers & nothing in the queue? done!
+		if workers == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case scanned := <-finishedScanningChan:
+			// the workers above the limit are not stopped, they are just not replaced once done
+			if controller != nil {
+				workersLimit = controller.observe(scanned.stats)
+				s.setWorkers(scan, workersLimit)
+			}
+
+			if s.template.dirEvents {
+				for i := range scanned.subDirs {
+					scanned.subDirs[i].node = scanned.dir.node.newChild()
 				}
 
-				// append to the queue
-				directoriesToScanQueue = append(directoriesToScanQueue, dir)
-			case scannedDir := <-finishedScanningChan:
-				delete(workers, scannedDir)
-
-				// still something in the queue?
-				if len(directoriesToScanQueue) > 0 {
-					dir := directoriesToScanQueue[0]
-					directoriesToScanQueue = directoriesToScanQueue[1:]
-					workers[dir] = dir
-					go doScan(ctx, dir, outFileItemChan, finishedScanningChan, scheduleScanningChan)
-					continue
+				for _, item := range scanned.dir.node.done(scanned.dirEnd) {
+					if emit(item) == walkStop {
+						return
+					}
 				}
+			}
 
-				// no workers & nothing in the queue? done!
-				if len(workers) == 0 {
-					close(doneChan)
+			var overflow []directory
+			for _, subDir := range scanned.subDirs {
+				ok, err := queue.push(subDir)
+				if err != nil && emit(FileItem{Err: err}) == walkStop {
 					return
 				}
+
+				if !ok {
+					overflow = append(overflow, subDir)
+				}
+			}
+
+			// overflowChan is buffered, so the reply never blocks
+			scanned.overflowChan <- overflow
+			if !scanned.hasMore && len(overflow) == 0 {
+				workers--
 			}
 		}
-	}()
+	}
+}
+
+// close releases the opened directory, once its items are emitted and its subdirectories have
+// retained it.
+func (d *directory) close() {
+	d.opened.release()
+	d.opened = nil
+}
+
+// newDirCache limits the directories a single scan keeps open, it's nil unless in the openat mode.
+func (s *RecursiveScanner) newDirCache() *dirCache {
+	if !s.template.openat {
+		return nil
+	}
+
+	return newDirCache(dirCacheLimit())
+}
+
+func (s *RecursiveScanner) rootDirectory(path string, dirs *dirCache) directory {
+	dir := directory{path: path, root: path, relPath: ".", dirs: dirs}
+	if s.followSymlinks || s.sameFilesystem {
+		if info, err := s.template.fsys.Stat(path); err == nil {
+			dir.info = info
+			dir.device, dir.hasDevice = deviceID(info)
+		}
+	}
+
+	if s.template.dirEvents {
+		dir.node = &dirNode{pending: 1}
+	}
+
+	return dir
+}
+
+func (s *RecursiveScanner) newDirectoryScanner(dir directory) (*BasicScanner, error) {
+	return NewBasicScanner(append(
+		s.scannerOptions[:len(s.scannerOptions):len(s.scannerOptions)],
+		WithDir(dir.path),
+		withRoot(dir.root, dir.relPath, dir.depth+1),
+		withParent(dir.parentDir),
+		withDirCache(dir.dirs),
+	)...)
+}
+
+func (s *RecursiveScanner) newQueue() directoryQueue {
+	if s.queueSpill {
+		return newSpillingQueue(s.queueLimit, s.queueTempDir)
+	}
+
+	return newMemoryQueue(s.queueLimit)
+}
+
+// doScan scans the directory and then the subdirectories the scheduler could not queue, the last
+// found first. The stack keeps the pending siblings of every directory on the way down, so it grows
+// with the depth times the fan-out of the tree, not with the depth alone.
+func (s *RecursiveScanner) doScan(ctx context.Context, dir directory, emit emitFn, finishedScanningChan chan scannedDirectory) {
+	var (
+		stack        = []directory{dir}
+		overflowChan = make(chan []directory, 1)
+	)
+
+	for len(stack) > 0 {
+		dir := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		scanned, ok := s.scanDirectory(ctx, dir, emit)
+		if !ok {
+			return
+		}
+
+		scanned.hasMore = len(stack) > 0
+		scanned.overflowChan = overflowChan
 
-	// shutdown
-	go func() {
 		select {
 		case <-ctx.Done():
-		case <-doneChan:
+			return
+		case finishedScanningChan <- scanned:
 		}
 
-		close(finishedScanningChan)
-		close(scheduleScanningChan)
-		close(outFileItemChan)
-	}()
+		select {
+		case <-ctx.Done():
+			return
+		case overflow := <-overflowChan:
+			stack = append(stack, overflow...)
+		}
+	}
+}
 
-	// schedule initial directories scanning
-	go func() {
-		for _, d := range s.directories {
-			scheduleScanningChan <- d
+func (s *RecursiveScanner) scanDirectory(ctx context.Context, dir directory, emit emitFn) (scannedDirectory, bool) {
+	var (
+		scanned = scannedDirectory{dir: dir}
+		visitor = listingVisitor{RecursiveScanner: s, dir: &dir, emit: emit}
+		start   = time.Now()
+
+		// waiting for the consumer is not the latency of the read
+		emitting time.Duration
+	)
+
+	defer dir.close()
+
+	for item := range s.directoryItems(ctx, &dir) {
+		if item.Kind == KindEntry {
+			scanned.stats.items++
 		}
-	}()
 
-	return outFileItemChan, nil
+		if item.Kind == KindDirEnd {
+			// directory is done when all of its subdirectories are done, see scannedDirectory
+			scanned.dirEnd = item
+			continue
+		}
+
+		emitStart := time.Now()
+		subDir, descend, ok := visitor.visit(item)
+		emitting += time.Since(emitStart)
+		if !ok {
+			return scanned, false
+		}
+
+		if descend {
+			scanned.subDirs = append(scanned.subDirs, subDir)
+		}
+	}
+
+	scanned.stats.latency = time.Since(start) - emitting
+	return scanned, ctx.Err() == nil
 }
 
-func doScan(ctx context.Context, dir string, outFileItemChan FileItemChan, finishedScanningChan, scheduleScanningChan chan string) {
-	defer func() { finishedScanningChan <- dir }()
+// directoryItems reads the listing of the directory. The ignore rules of the directory are loaded
+// before the first item, so its subdirectories inherit them. In the openat mode the directory is
+// left opened, for the caller to close it once the items are emitted.
+func (s *RecursiveScanner) directoryItems(ctx context.Context, dir *directory) iter.Seq[FileItem] {
+	return func(yield func(FileItem) bool) {
+		var (
+			d      dirFile
+			opened *Dir
+		)
 
-	defer func() {
-		if err := recover(); err != nil {
-			outFileItemChan <- FileItem{nil, err.(error)}
+		scanner, err := s.newDirectoryScanner(*dir)
+		if err == nil {
+			d, opened, err = scanner.openDir()
 		}
-	}()
 
-	for item := range MustScan(MustScanner(NewBasicScanner(WithDir(dir))).Scan(ctx)) {
-		if item.FileInfo.IsDir() {
-			scheduleScanningChan <- item.FileInfo.PathName()
+		// parent is not needed anymore once the directory is opened relative to it
+		dir.parentDir.release()
+		dir.parentDir = nil
+
+		if err != nil {
+			yield(FileItem{Err: err})
+			return
+		}
+
+		dir.opened = opened
+
+		if s.gitignore {
+			if err := s.loadIgnoreRules(dir); err != nil && !yield(FileItem{Err: err}) {
+				if opened == nil {
+					d.Close()
+				} else {
+					opened.unpin()
+				}
+
+				return
+			}
 		}
 
-		outFileItemChan <- item
+		scanner.read(ctx, d, opened, func(item FileItem) bool {
+			return (s.gitignore && dir.ignore.ignored(item)) || yield(item)
+		})
+	}
+}
+
+// listingVisitor emits the items of a single directory listing, the way the consumer wants it.
+type listingVisitor struct {
+	*RecursiveScanner
+	dir      *directory
+	emit     emitFn
+	skipRest bool
+}
+
+// visit emits the item, except for the DirEnd one, and tells whether it is the directory to
+// descend into. False means the consumer is gone.
+func (v *listingVisitor) visit(item FileItem) (directory, bool, bool) {
+	if v.skipRest && item.Kind == KindEntry {
+		return directory{}, false, true
+	}
+
+	depth := v.dir.depth + 1
+	subDir, descend := v.subDirectory(v.dir, &item, depth)
+
+	if item.Kind == KindEntry && depth < v.minDepth {
+		return subDir, descend, true
 	}
+
+	switch v.emit(item) {
+	case walkStop:
+		subDir.parentDir.release()
+		return directory{}, false, false
+	case walkSkipDir:
+		// skipped directory is never descended into, anything else skips the rest of the listing
+		if item.Kind != KindEntry || !(descend || (item.FileInfo != nil && item.FileInfo.IsDir())) {
+			v.skipRest = true
+		}
+
+		subDir.parentDir.release()
+		return directory{}, false, true
+	}
+
+	return subDir, descend, true
+}
+
+// subDirectory tells whether the item is the directory to descend into. The followed symlink is
+// replaced with its target by then, see followSymlink.
+func (s *RecursiveScanner) subDirectory(parent *directory, item *FileItem, depth uint) (directory, bool) {
+	if item.Kind != KindEntry || item.FileInfo == nil {
+		return directory{}, false
+	}
+
+	if s.followSymlinks {
+		s.followSymlink(item)
+	}
+
+	if s.maxDepth > 0 && depth >= s.maxDepth {
+		return directory{}, false
+	}
+
+	// pruned directory is reported, but never read
+	if s.prune != nil && s.prune.Match(*item) {
+		return directory{}, false
+	}
+
+	info := item.FileInfo
+	if !info.IsDir() {
+		return directory{}, false
+	}
+
+	subDir := directory{
+		path:    item.FileInfo.PathName(),
+		root:    parent.root,
+		relPath: item.FileInfo.RelPath(),
+		depth:   depth,
+
+		// the directory is opened relative to the one it has been found in, see directoryItems
+		parentDir: item.Dir,
+		ignore:    parent.ignore,
+	}
+	if s.followSymlinks {
+		// symlink pointing back at one of the ancestors would loop forever
+		if parent.isDescendantOf(info) {
+			return directory{}, false
+		}
+
+		subDir.parent = parent
+		subDir.info = info
+	}
+
+	if s.sameFilesystem && parent.hasDevice {
+		// mount point itself is reported, but never descended into
+		if device, ok := deviceID(info); ok && device != parent.device {
+			return directory{}, false
+		}
+
+		subDir.device, subDir.hasDevice = parent.device, true
+	}
+
+	subDir.parentDir.retain()
+	return subDir, true
+}
+
+// followSymlink reports the followed symlink the way find -L does, with the FileInfo of its target
+// under the path of the symlink. Dangling symlink is reported as is.
+func (s *RecursiveScanner) followSymlink(item *FileItem) {
+	file, ok := item.FileInfo.(File)
+	if !ok || fileType(file.FileInfo)&os.ModeSymlink == 0 {
+		return
+	}
+
+	target, err := s.template.fsys.Stat(file.PathName())
+	if err != nil {
+		return
+	}
+
+	file.FileInfo = target
+	item.FileInfo = file
 }
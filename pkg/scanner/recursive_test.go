@@ -3,7 +3,12 @@ package scanner_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -24,6 +29,13 @@ func TestRecursiveScannerOptions(t *testing.T) {
 		err := WithDirectories()(&RecursiveScanner{})
 		Expect(err).ToNot(HaveOccurred())
 	}))
+
+	t.Run("When min depth is greater than max depth", ScannerTest(func(t *testing.T) {
+		s, err := NewRecursiveScanner(WithMaxDepth(1), WithMinDepth(2))
+
+		Expect(err).To(Equal(ErrInvalidDepthRange))
+		Expect(s).To(BeNil())
+	}))
 }
 
 func TestRecursiveScanner(t *testing.T) {
@@ -285,6 +297,394 @@ func TestRecursiveScanner(t *testing.T) {
 	}))
 
 	t.Run("When context is terminated", ScannerTest(func(t *testing.T) {
-		t.Skip("TODO")
+		dir := NewDirectoryPath("nested-directory-to-cancel")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("level-0-directory-1",
+				NewWorkspaceDir("level-1-directory-1.1", NewWorkspaceFiles("level-2-file-1.1", 20)...),
+				NewWorkspaceDir("level-1-directory-1.2", NewWorkspaceFiles("level-2-file-1.2", 20)...),
+			),
+			NewWorkspaceDir("level-0-directory-2", NewWorkspaceFiles("level-1-file-2", 20)...),
+			NewWorkspaceDir("level-0-directory-3", NewWorkspaceFiles("level-1-file-3", 20)...),
+		)).Purge()
+
+		for _, strategy := range []Strategy{StrategyParallel, StrategyDepthFirst, StrategyBreadthFirst} {
+			ExpectCancelToStopScan(MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithWorkers(2),
+				WithStrategy(strategy),
+				WithScannerOptions(WithDirEvents()),
+			)))
+		}
+
+		ExpectCancelToStopScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(2), WithQueueSpill(1, ""))))
+	}))
+}
+
+func TestRecursiveScannerDepth(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-with-3-level-depth")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFile("level-2-file-1.2.1.jpg"),
+				NewWorkspaceDir("level-2-directory-1.2.2",
+					NewWorkspaceFile("level-3-file-1.2.2.1.jpg"),
+				),
+			),
+		),
+	)).Purge()
+
+	var testCases = []struct {
+		Name        string
+		Options     []RecursiveScannerOptionFn
+		Directories int
+		Files       int
+	}{
+		{"When max depth is 1", []RecursiveScannerOptionFn{WithMaxDepth(1)}, 1, 1},
+		{"When max depth is 2", []RecursiveScannerOptionFn{WithMaxDepth(2)}, 2, 2},
+		{"When max depth exceeds the tree", []RecursiveScannerOptionFn{WithMaxDepth(10)}, 3, 4},
+		{"When min depth is 2", []RecursiveScannerOptionFn{WithMinDepth(2)}, 2, 3},
+		{"When min depth exceeds the tree", []RecursiveScannerOptionFn{WithMinDepth(10)}, 0, 0},
+		{"When min & max depth are equal", []RecursiveScannerOptionFn{WithMinDepth(2), WithMaxDepth(2)}, 1, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, ScannerTest(func(t *testing.T) {
+			options := append([]RecursiveScannerOptionFn{WithDirectories(dir)}, tc.Options...)
+			fileChan, err := MustScanner(NewRecursiveScanner(options...)).Scan(context.TODO())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+				HaveLen(tc.Directories+tc.Files),
+				HaveDirectories(tc.Directories),
+				HaveRegularFiles(tc.Files),
+			)))
+		}))
+	}
+}
+
+func TestRecursiveScannerSymlinks(t *testing.T) {
+	dir := NewDirectoryPath("directory-with-symlinks")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceSymlink("level-1-link-to-parent", ".."),
+		),
+		NewWorkspaceSymlink("level-0-link-to-directory-1", "level-0-directory-1"),
+		NewWorkspaceSymlink("level-0-dangling-link", "does-not-exist"),
+	)).Purge()
+
+	t.Run("When symlinks are not followed", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(WithDirectories(dir))).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(6),
+			HaveDirectories(1),
+			HaveRegularFiles(2),
+		)))
+	}))
+
+	t.Run("When symlinks are followed", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithFollowSymlinks())).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		// symlinks are reported with the FileInfo of the target, the way find -L does
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(8),
+			HaveDirectories(4),
+			HaveRegularFiles(3),
+		)))
+	}))
+
+	t.Run("When followed symlinks are filtered", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-with-filtered-symlinks")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceFile("file.jpg"),
+			NewWorkspaceSymlink("link-to-file", "file.jpg"),
+			NewWorkspaceDir("directory",
+				NewWorkspaceDir("sub"),
+			),
+			NewWorkspaceSymlink("link-to-directory", "directory"),
+		)).Purge()
+
+		for _, strategy := range []Strategy{StrategyParallel, StrategyDepthFirst, StrategyBreadthFirst} {
+			s := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithStrategy(strategy), WithFollowSymlinks()))
+
+			Expect(SortedPathNames(FileChanToPathNames(MustScan(NewFilterRegularFilesScanner(s).Scan(context.TODO()))))).To(Equal([]string{
+				dir + "/file.jpg",
+				dir + "/link-to-file",
+			}))
+			Expect(SortedPathNames(FileChanToPathNames(MustScan(NewFilterDirectoriesScanner(s).Scan(context.TODO()))))).To(Equal([]string{
+				dir + "/directory",
+				dir + "/directory/sub",
+				dir + "/link-to-directory",
+				dir + "/link-to-directory/sub",
+			}))
+		}
+	}))
+
+	t.Run("When symlink points at an ancestor below the root", ScannerTest(func(t *testing.T) {
+		dir := NewDirectoryPath("directory-with-nested-symlink")
+		defer MustNewWorkspace(dir, WithItems(
+			NewWorkspaceDir("a",
+				NewWorkspaceDir("b",
+					NewWorkspaceSymlink("up", ".."),
+				),
+			),
+		)).Purge()
+
+		fileChan, err := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithFollowSymlinks())).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{
+			dir + "/a",
+			dir + "/a/b",
+			dir + "/a/b/up",
+		}))
+	}))
+}
+
+func TestRecursiveScannerSameFilesystem(t *testing.T) {
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("requires procfs mounted at /proc")
+	}
+
+	dir := NewDirectoryPath("directory-with-link-to-other-filesystem")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+		),
+		NewWorkspaceSymlink("level-0-link-to-proc", "/proc/self"),
+	)).Purge()
+
+	t.Run("When symlinked directory is placed on the other filesystem", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithFollowSymlinks(),
+			WithSameFilesystem(),
+		)).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(3),
+			HaveDirectories(2),
+			HaveRegularFiles(1),
+		)))
+	}))
+}
+
+func TestRecursiveScannerMetadata(t *testing.T) {
+	firstDir := NewDirectoryPath("first-nested-directory-with-metadata")
+	defer MustNewWorkspace(firstDir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFile("level-2-file-1.2.1.jpg"),
+			),
+		),
+	)).Purge()
+
+	secondDir := NewDirectoryPath("second-nested-directory-with-metadata")
+	defer MustNewWorkspace(secondDir, WithItems(
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+		),
+	)).Purge()
+
+	for _, strategy := range []Strategy{StrategyParallel, StrategyDepthFirst, StrategyBreadthFirst} {
+		t.Run(fmt.Sprintf("When strategy is %d", strategy), ScannerTest(func(t *testing.T) {
+			fileChan, err := MustScanner(NewRecursiveScanner(
+				WithDirectories(firstDir, secondDir),
+				WithStrategy(strategy),
+				WithScannerOptions(WithDirEvents()),
+				WithQueueSpill(1, ""),
+			)).Scan(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			files := FileChanToSlice(fileChan)
+			Expect(files).To(HaveLen(7 + 2*5))
+
+			roots := make(map[string]int)
+			for _, file := range files {
+				info := file.FileInfo
+				roots[info.Root()]++
+
+				Expect(info.PathName()).To(Equal(path.Join(info.Root(), info.RelPath())))
+				if info.RelPath() == "." {
+					Expect(info.Depth()).To(BeZero())
+				} else {
+					Expect(info.Depth()).To(Equal(uint(strings.Count(info.RelPath(), "/") + 1)))
+				}
+			}
+
+			Expect(roots).To(Equal(map[string]int{firstDir: 5 + 2*3, secondDir: 2 + 2*2}))
+		}))
+	}
+}
+
+func TestRecursiveScannerPrune(t *testing.T) {
+	dir := NewDirectoryPath("directory-with-pruned-subtree")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceDir("src",
+			NewWorkspaceFile("index.js"),
+		),
+		NewWorkspaceDir("node_modules",
+			NewWorkspaceFile("index.js"),
+			NewWorkspaceDir("dependency",
+				NewWorkspaceFile("index.js"),
+			),
+		),
+	)).Purge()
+
+	nodeModulesFilter := RegExpFilter(regexp.MustCompile("^node_modules$"))
+
+	t.Run("When single prune filter is passed", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(WithDirectories(dir), WithPrune(nodeModulesFilter))).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(3),
+			HaveDirectories(2),
+			HaveRegularFiles(1),
+		)))
+	}))
+
+	t.Run("When many prune filters are passed", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithPrune(nodeModulesFilter),
+			WithPrune(RegExpFilter(regexp.MustCompile("^src$"))),
+		)).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileChan).To(WithTransform(FileChanToSlice, And(
+			HaveLen(2),
+			HaveDirectories(2),
+		)))
 	}))
 }
+
+func TestRecursiveScannerStrategy(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-traverse")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceFile("level-2-file-1.2.1.jpg"),
+				NewWorkspaceDir("level-2-directory-1.2.2",
+					NewWorkspaceFile("level-3-file-1.2.2.1.jpg"),
+				),
+			),
+		),
+		NewWorkspaceDir("level-0-directory-2",
+			NewWorkspaceFile("level-1-file-2.1.jpg"),
+			NewWorkspaceDir("level-1-directory-2.2",
+				NewWorkspaceFile("level-2-file-2.2.1.jpg"),
+			),
+		),
+		NewWorkspaceFile("level-0-file-3.jpg"),
+	)).Purge()
+
+	depthOf := func(pathName string) int {
+		return strings.Count(strings.TrimPrefix(pathName, dir), "/")
+	}
+
+	t.Run("When breadth-first strategy is set", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithWorkers(3),
+			WithStrategy(StrategyBreadthFirst),
+		)).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+
+		pathNames := FileChanToPathNames(fileChan)
+		Expect(pathNames).To(HaveLen(12))
+		for i := 1; i < len(pathNames); i++ {
+			Expect(depthOf(pathNames[i-1])).To(BeNumerically("<=", depthOf(pathNames[i])))
+		}
+	}))
+
+	t.Run("When depth-first strategy is set", ScannerTest(func(t *testing.T) {
+		fileChan, err := MustScanner(NewRecursiveScanner(
+			WithDirectories(dir),
+			WithWorkers(3),
+			WithStrategy(StrategyDepthFirst),
+		)).Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+
+		pathNames := FileChanToPathNames(fileChan)
+		Expect(pathNames).To(HaveLen(12))
+		for i := 1; i < len(pathNames); i++ {
+			// every item is either a child of the previous one, or a sibling of the previous one or its ancestors
+			Expect(depthOf(pathNames[i])).To(BeNumerically("<=", depthOf(pathNames[i-1])+1))
+			if depthOf(pathNames[i]) > depthOf(pathNames[i-1]) {
+				Expect(strings.HasPrefix(pathNames[i], pathNames[i-1]+"/")).To(BeTrue())
+			}
+		}
+	}))
+}
+
+func TestRecursiveScannerDirEvents(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-with-dir-events")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+			NewWorkspaceDir("level-1-directory-1.2",
+				NewWorkspaceDir("level-2-directory-1.2.1"),
+				NewWorkspaceDir("level-2-directory-1.2.2",
+					NewWorkspaceFile("level-3-file-1.2.2.1.jpg"),
+				),
+			),
+		),
+		NewWorkspaceDir("level-0-directory-2",
+			NewWorkspaceFiles("level-1-file-2", 5)...,
+		),
+	)).Purge()
+
+	for _, strategy := range []Strategy{StrategyParallel, StrategyDepthFirst, StrategyBreadthFirst} {
+		t.Run(fmt.Sprintf("When strategy is %d", strategy), ScannerTest(func(t *testing.T) {
+			fileChan, err := MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithWorkers(2),
+				WithStrategy(strategy),
+				WithScannerOptions(WithDirEvents()),
+			)).Scan(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			var (
+				files  = FileChanToSlice(fileChan)
+				starts = make(map[string]int)
+				ends   = make(map[string]int)
+			)
+
+			for i, file := range files {
+				switch file.Kind {
+				case KindDirStart:
+					starts[file.FileInfo.PathName()] = i
+				case KindDirEnd:
+					ends[file.FileInfo.PathName()] = i
+				}
+			}
+
+			Expect(files).To(HaveLen(13 + 2*6))
+			Expect(starts).To(HaveLen(6))
+			Expect(ends).To(HaveLen(6))
+
+			// every item is placed between the events of all its ancestors
+			for i, file := range files {
+				for p := path.Dir(file.FileInfo.PathName()); strings.HasPrefix(p, dir); p = path.Dir(p) {
+					Expect(starts).To(HaveKeyWithValue(p, BeNumerically("<", i)))
+					Expect(ends).To(HaveKeyWithValue(p, BeNumerically(">", i)))
+				}
+			}
+		}))
+	}
+}
@@ -3,33 +3,106 @@ package scanner
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"os"
 	"path"
 )
 
-var ErrNotDirectory = errors.New("not a directory")
+var (
+	ErrNotDirectory      = errors.New("not a directory")
+	ErrInvalidDepthRange = errors.New("min depth is greater than max depth")
+	ErrNotFile           = errors.New("item has no file")
+)
 
 type FileInfo interface {
 	os.FileInfo
 	PathName() string
+	// Root is the scanned directory the file has been found in
+	Root() string
+	// RelPath is the path of the file relative to its root, "." for the root itself
+	RelPath() string
+	// Depth is 1 for the files placed directly in the root, 0 for the root itself
+	Depth() uint
 }
 
 type File struct {
 	os.FileInfo
 	pathName string
+	root     string
+	relPath  string
+	depth    uint
 }
 
 func (f File) PathName() string {
 	return path.Join(f.pathName, f.Name())
 }
 
+// Type is the type bits of the mode, known without the stat in the lazy-stat mode, see WithLazyStat.
+func (f File) Type() fs.FileMode {
+	return fileType(f.FileInfo)
+}
+
+// Info returns the stat of the file, which fails in the lazy-stat mode when the file is gone.
+func (f File) Info() (os.FileInfo, error) {
+	if lazy, ok := f.FileInfo.(*lazyFileInfo); ok {
+		return lazy.Info()
+	}
+
+	return f.FileInfo, nil
+}
+
+func (f File) Root() string {
+	return f.root
+}
+
+func (f File) RelPath() string {
+	return f.relPath
+}
+
+func (f File) Depth() uint {
+	return f.depth
+}
+
+// NewFile creates the file placed directly in the pathName, which becomes its root.
 func NewFile(info os.FileInfo, pathName string) File {
-	return File{info, pathName}
+	return File{info, pathName, pathName, info.Name(), 1}
 }
 
+// NewRootedFile creates the file placed at relPath inside of the root.
+func NewRootedFile(info os.FileInfo, root, relPath string, depth uint) File {
+	return File{info, path.Dir(path.Join(root, relPath)), root, relPath, depth}
+}
+
+type ItemKind int8
+
+const (
+	// KindEntry is the regular item found in the scanned directory
+	KindEntry ItemKind = iota
+	// KindDirStart is emitted before any item of the scanned directory
+	KindDirStart
+	// KindDirEnd is emitted after all the items of the scanned directory, including the nested ones
+	KindDirEnd
+)
+
 type FileItem struct {
 	FileInfo FileInfo
 	Err      error
+	Kind     ItemKind
+	// Dir is the opened directory the file is placed in, set in the openat mode only, see WithOpenat
+	Dir *Dir
+}
+
+// Open opens the file for reading, relative to its directory in the openat mode.
+func (f FileItem) Open() (*os.File, error) {
+	if f.FileInfo == nil {
+		return nil, ErrNotFile
+	}
+
+	if f.Dir != nil {
+		return f.Dir.Open(f.FileInfo.Name())
+	}
+
+	return os.Open(f.FileInfo.PathName())
 }
 
 func (f FileItem) String() string {
@@ -46,6 +119,17 @@ func (f FileItem) String() string {
 
 type FileItemChan chan FileItem
 
+// send delivers the item unless the context is done first. False means the consumer is gone and
+// the producer has to stop.
+func send(ctx context.Context, fileChan FileItemChan, item FileItem) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case fileChan <- item:
+		return true
+	}
+}
+
 type Scanner interface {
 	Scan(ctx context.Context) (FileItemChan, error)
 }
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 
 	. "github.com/wojteninho/scanner/pkg/scanner"
@@ -34,23 +35,25 @@ func BenchmarkSimpleScannerBulkSize(b *testing.B) {
 type MakeScanFn func(directory string) ScanFn
 type ScanFn func()
 
-func makeSimpleScannerScanFn(directory string) ScanFn {
-	return func() {
-		var doneChan = make(chan struct{})
+func makeSimpleScannerScanFn(options ...BasicScannerOptionFn) MakeScanFn {
+	return func(directory string) ScanFn {
+		return func() {
+			var doneChan = make(chan struct{})
 
-		go func() {
-			for item := range MustScan(MustScanner(NewBasicScanner(WithDir(directory))).Scan(context.TODO())) {
-				if item.Err != nil {
-					panic(item.Err)
-				}
+			go func() {
+				for item := range MustScan(MustScanner(NewBasicScanner(append(options, WithDir(directory))...)).Scan(context.TODO())) {
+					if item.Err != nil {
+						panic(item.Err)
+					}
 
-				item.FileInfo.Name()
-			}
+					item.FileInfo.Name()
+				}
 
-			close(doneChan)
-		}()
+				close(doneChan)
+			}()
 
-		<-doneChan
+			<-doneChan
+		}
 	}
 }
 
@@ -107,7 +110,9 @@ func BenchmarkCompareWithOtherMethodsByScanningFlatDirectory(b *testing.B) {
 		Name       string
 		MakeScanFn MakeScanFn
 	}{
-		{Name: "BasicScanner.Scan", MakeScanFn: makeSimpleScannerScanFn},
+		{Name: "BasicScanner.Scan", MakeScanFn: makeSimpleScannerScanFn()},
+		{Name: "BasicScanner.Scan+LazyStat", MakeScanFn: makeSimpleScannerScanFn(WithLazyStat())},
+		{Name: "BasicScanner.Scan+Getdents", MakeScanFn: makeSimpleScannerScanFn(WithGetdents())},
 		{Name: "filepath.Walk", MakeScanFn: makeFilepathWalkFn},
 		{Name: "ioutil.ReadDir", MakeScanFn: makeIoutilReadDirFn},
 		{Name: "os.File.Readdir", MakeScanFn: makeOsFileReaddirFn},
@@ -129,3 +134,39 @@ func BenchmarkCompareWithOtherMethodsByScanningFlatDirectory(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkLazyStat(b *testing.B) {
+	var filters = []struct {
+		Name   string
+		Filter Filter
+	}{
+		{Name: "ExtensionFilter", Filter: ExtensionFilter(".jpg")},
+		{Name: "RegExpFilter", Filter: RegExpFilter(regexp.MustCompile(`-1\d*$`))},
+	}
+
+	for _, filter := range filters {
+		for _, filesNumber := range []uint{1000, 10000, 100000} {
+			for _, lazyStat := range []bool{false, true} {
+				b.Run(fmt.Sprintf("%s/filesNumber-%d/lazyStat-%t", filter.Name, filesNumber, lazyStat), func(b *testing.B) {
+					b.StopTimer()
+					dir := NewDirectoryPath(fmt.Sprintf("directory-with-%d-files", filesNumber))
+					defer MustNewWorkspace(dir, WithItems(NewWorkspaceFiles("file", filesNumber)...)).Purge()
+
+					options := []BasicScannerOptionFn{WithDir(dir), WithBulkSize(1000)}
+					if lazyStat {
+						options = append(options, WithLazyStat())
+					}
+
+					scanner := NewFilterScanner(MustScanner(NewBasicScanner(options...)), filter.Filter)
+					b.StartTimer()
+
+					for i := 0; i < b.N; i++ {
+						for f := range MustScan(scanner.Scan(context.TODO())) {
+							f.FileInfo.Name()
+						}
+					}
+				})
+			}
+		}
+	}
+}
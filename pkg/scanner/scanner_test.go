@@ -1,15 +1,22 @@
 package scanner_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -73,6 +80,23 @@ func TestFile(t *testing.T) {
 		file := NewFile(f, filepath.Dir(filename))
 		Expect(file.PathName()).To(Equal(path.Join(filepath.Dir(filename), f.Name())))
 	}))
+
+	t.Run("When calling Root, RelPath and Depth", ScannerTest(func(t *testing.T) {
+		_, filename, _, _ := runtime.Caller(0)
+		f, err := os.Stat(filename)
+		Expect(err).ToNot(HaveOccurred())
+
+		file := NewFile(f, filepath.Dir(filename))
+		Expect(file.Root()).To(Equal(filepath.Dir(filename)))
+		Expect(file.RelPath()).To(Equal(f.Name()))
+		Expect(file.Depth()).To(Equal(uint(1)))
+
+		root := filepath.Dir(filepath.Dir(filename))
+		file = NewRootedFile(f, root, path.Join(filepath.Base(filepath.Dir(filename)), f.Name()), 2)
+		Expect(file.PathName()).To(Equal(filename))
+		Expect(file.Root()).To(Equal(root))
+		Expect(file.Depth()).To(Equal(uint(2)))
+	}))
 }
 
 func TestMustScanner(t *testing.T) {
@@ -145,6 +169,18 @@ func NewWorkspaceDir(name string, items ...WorkspaceItem) WorkspaceDir {
 	}
 }
 
+type WorkspaceSymlink struct {
+	WorkspaceFile
+	target string
+}
+
+func NewWorkspaceSymlink(name, target string) WorkspaceSymlink {
+	return WorkspaceSymlink{
+		WorkspaceFile{name},
+		target,
+	}
+}
+
 func WithItems(items ...WorkspaceItem) WorkspaceOptionFn {
 	return func(w *Workspace) error {
 		w.items = items
@@ -220,6 +256,14 @@ func createItems(directory string, permission os.FileMode, items ...WorkspaceIte
 			continue
 		}
 
+		if linkItem, ok := item.(WorkspaceSymlink); ok {
+			if err := os.Symlink(linkItem.target, path.Join(directory, linkItem.Name())); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		if err := createFile(path.Join(directory, item.Name()), permission); err != nil {
 			return err
 		}
@@ -243,6 +287,12 @@ func createFile(name string, permission os.FileMode) error {
 	return os.Chmod(name, permission)
 }
 
+func MustWriteFile(name string, content string) {
+	if err := ioutil.WriteFile(name, []byte(content), os.ModePerm); err != nil {
+		panic(err)
+	}
+}
+
 func debug(directory string) {
 	pr, pw := io.Pipe()
 	defer pw.Close()
@@ -296,6 +346,69 @@ func FileChanToSlice(fileChan FileItemChan) FileSlice {
 	return files
 }
 
+func FileChanToPathNames(fileChan FileItemChan) []string {
+	var pathNames []string
+
+	for file := range fileChan {
+		pathNames = append(pathNames, file.String())
+	}
+
+	return pathNames
+}
+
+var scanLabels atomic.Uint64
+
+// ExpectCancelToStopScan cancels the scan after the first item, while nobody reads the channel
+// anymore, and expects all the goroutines of the scan to exit and the channel to get closed. The
+// goroutines are told by the profiler label inherited from the Scan call, so the ones of the other
+// tests, still exiting, don't count.
+func ExpectCancelToStopScan(s Scanner) {
+	var (
+		label       = strconv.FormatUint(scanLabels.Add(1), 10)
+		ctx, cancel = context.WithCancel(context.Background())
+		fileChan    FileItemChan
+		err         error
+	)
+
+	pprof.Do(ctx, pprof.Labels("scan", label), func(ctx context.Context) {
+		fileChan, err = s.Scan(ctx)
+	})
+
+	Expect(err).ToNot(HaveOccurred())
+	Eventually(fileChan).Should(Receive())
+
+	cancel()
+
+	Eventually(func() int { return LabeledGoroutines("scan", label) }, time.Second).Should(BeZero())
+	Expect(fileChan).To(BeClosed())
+}
+
+// LabeledGoroutines counts the goroutines having the profiler label.
+func LabeledGoroutines(key, value string) int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		panic(err)
+	}
+
+	// header is followed by the records, every one starts with the number of goroutines sharing
+	// the stack & the labels
+	_, profile, _ := strings.Cut(buf.String(), "\n")
+
+	var count int
+	for _, record := range strings.Split(profile, "\n\n") {
+		if !strings.Contains(record, strconv.Quote(key)+":"+strconv.Quote(value)) {
+			continue
+		}
+
+		n, _, _ := strings.Cut(record, " ")
+		if i, err := strconv.Atoi(n); err == nil {
+			count += i
+		}
+	}
+
+	return count
+}
+
 // custom matchers
 type HaveFilesMatcher struct {
 	matchers.HaveLenMatcher
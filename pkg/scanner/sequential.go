@@ -0,0 +1,218 @@
+package scanner
+
+import (
+	"context"
+	"iter"
+)
+
+// All iterates over the directories in the calling goroutine when there is a single worker,
+// otherwise over the items of the workers pool.
+func (s *RecursiveScanner) All(ctx context.Context) iter.Seq2[FileInfo, error] {
+	return entries(s.items(ctx))
+}
+
+func (s *RecursiveScanner) items(ctx context.Context) iter.Seq[FileItem] {
+	if s.workers > 1 {
+		return scanItems(ctx, s)
+	}
+
+	return func(yield func(FileItem) bool) {
+		s.scanSequential(ctx, yieldEmit(yield))
+	}
+}
+
+func (s *RecursiveScanner) walk(ctx context.Context, emit emitFn) {
+	if s.workers > 1 && s.strategy == StrategyParallel && s.template.order == OrderNone {
+		s.scanParallel(ctx, emit)
+		return
+	}
+
+	// ordered scan reads the directories ahead, before the consumer could skip them
+	s.scanSequential(ctx, emit)
+}
+
+// traversal is deterministic only when the directories are sorted by their names, which is the
+// order of the paths then.
+func (s *RecursiveScanner) traversal() (traversal, bool) {
+	if s.template.order != OrderLexical && s.template.order != OrderNatural {
+		return traversal{}, false
+	}
+
+	if s.strategy == StrategyBreadthFirst {
+		return traversal{Kind: traversalBreadthFirst, Order: s.template.order}, true
+	}
+
+	return traversal{Kind: traversalDepthFirst, Order: s.template.order}, true
+}
+
+// seek scans in the calling goroutine, see walk.
+func (s *RecursiveScanner) seek(ctx context.Context, pos position) iter.Seq[FileItem] {
+	t, _ := s.traversal()
+	return t.seek(ctx, s, pos)
+}
+
+// scanSequential scans the directories in the calling goroutine, in the same order the workers
+// pool does, see Scan.
+func (s *RecursiveScanner) scanSequential(ctx context.Context, emit emitFn) {
+	if s.strategy == StrategyDepthFirst || (s.strategy == StrategyParallel && s.template.order != OrderNone) {
+		s.walkDepthFirst(ctx, emit)
+		return
+	}
+
+	s.walkQueue(ctx, emit)
+}
+
+func (s *RecursiveScanner) walkDepthFirst(ctx context.Context, emit emitFn) {
+	directories := append([]string(nil), s.directories...)
+	s.template.order.sortDirectories(directories)
+
+	dirs := s.newDirCache()
+	defer dirs.close()
+
+	for _, d := range directories {
+		if !s.walkDirectory(ctx, s.rootDirectory(d, dirs), emit) {
+			return
+		}
+	}
+}
+
+// walkDirectory reads the whole listing upfront, so there is a single directory open at a time.
+func (s *RecursiveScanner) walkDirectory(ctx context.Context, dir directory, emit emitFn) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var (
+		visitor = listingVisitor{RecursiveScanner: s, dir: &dir, emit: emit}
+		listing []FileItem
+	)
+
+	defer dir.close()
+
+	for item := range s.directoryItems(ctx, &dir) {
+		listing = append(listing, item)
+	}
+
+	// listing starts with DirStart and ends with DirEnd, so the events are in place already
+	for _, item := range listing {
+		if item.Kind == KindDirEnd {
+			if emit(item) == walkStop {
+				return false
+			}
+
+			continue
+		}
+
+		subDir, descend, ok := visitor.visit(item)
+		if !ok {
+			return false
+		}
+
+		if descend && !s.walkDirectory(ctx, subDir, emit) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// walkQueue visits the directories in the order a single worker of the pool does: the queued ones
+// first in first out, the ones which did not fit into the queue right after their parent.
+func (s *RecursiveScanner) walkQueue(ctx context.Context, emit emitFn) {
+	var (
+		queue       directoryQueue = newMemoryQueue(0)
+		overflow    []directory
+		directories = append([]string(nil), s.directories...)
+	)
+
+	// limits apply to the parallel strategy only, strict breadth-first needs the whole level queued
+	if s.strategy == StrategyParallel {
+		queue = s.newQueue()
+	}
+
+	defer queue.close()
+
+	dirs := s.newDirCache()
+	defer dirs.close()
+
+	s.template.order.sortDirectories(directories)
+
+	// roots are scanned in the given order
+	for i := len(directories) - 1; i >= 0; i-- {
+		overflow = append(overflow, s.rootDirectory(directories[i], dirs))
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var dir directory
+		switch {
+		case len(overflow) > 0:
+			dir, overflow = overflow[len(overflow)-1], overflow[:len(overflow)-1]
+		case queue.len() > 0:
+			var err error
+			if dir, _, err = queue.pop(); err != nil {
+				emit(FileItem{Err: err})
+				return
+			}
+		default:
+			return
+		}
+
+		var (
+			visitor = listingVisitor{RecursiveScanner: s, dir: &dir, emit: emit}
+			subDirs []directory
+			dirEnd  FileItem
+		)
+
+		for item := range s.directoryItems(ctx, &dir) {
+			if item.Kind == KindDirEnd {
+				// directory is done when all of its subdirectories are done, see dirNode
+				dirEnd = item
+				continue
+			}
+
+			subDir, descend, ok := visitor.visit(item)
+			if !ok {
+				return
+			}
+
+			if descend {
+				subDirs = append(subDirs, subDir)
+			}
+		}
+
+		dir.close()
+
+		if s.template.dirEvents {
+			for i := range subDirs {
+				subDirs[i].node = dir.node.newChild()
+			}
+
+			for _, item := range dir.node.done(dirEnd) {
+				if emit(item) == walkStop {
+					return
+				}
+			}
+		}
+
+		var full []directory
+		for _, subDir := range subDirs {
+			ok, err := queue.push(subDir)
+			if err != nil && emit(FileItem{Err: err}) == walkStop {
+				return
+			}
+
+			if !ok {
+				full = append(full, subDir)
+			}
+		}
+
+		// the first one which did not fit goes next
+		for i := len(full) - 1; i >= 0; i-- {
+			overflow = append(overflow, full[i])
+		}
+	}
+}
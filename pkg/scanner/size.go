@@ -0,0 +1,182 @@
+package scanner
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const nameSizeFilter = "SizeFilter"
+
+var ErrInvalidSize = errors.New("invalid size")
+
+// Decimal & binary size units.
+const (
+	KB int64 = 1000
+	MB       = 1000 * KB
+	GB       = 1000 * MB
+	TB       = 1000 * GB
+
+	KiB int64 = 1024
+	MiB       = 1024 * KiB
+	GiB       = 1024 * MiB
+	TiB       = 1024 * GiB
+)
+
+// SizeMode tells which size of the file the size filters compare.
+type SizeMode int8
+
+const (
+	// SizeApparent is the length of the content, FileInfo.Size
+	SizeApparent SizeMode = iota
+	// SizeAllocated is the disk space allocated for the file, the way du counts it. It is smaller than
+	// the apparent one for the sparse files and bigger for the small ones. It needs the Stat_t of Unix
+	// or WithStatx, the other files never match.
+	SizeAllocated
+)
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   KiB,
+	"m":   MiB,
+	"g":   GiB,
+	"t":   TiB,
+	"kb":  KB,
+	"mb":  MB,
+	"gb":  GB,
+	"tb":  TB,
+	"kib": KiB,
+	"mib": MiB,
+	"gib": GiB,
+	"tib": TiB,
+}
+
+// ParseSize parses the size with an optional unit, e.g. 512, 1.5G or 10KiB. KB, MB, GB & TB are
+// decimal, single letters are binary the way find(1) and du(1) have them, the same as KiB, MiB etc.
+// Units are case-insensitive.
+func ParseSize(s string) (int64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(s[i:])]
+	if !ok || i == 0 {
+		return 0, ErrInvalidSize
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n*float64(unit) >= math.MaxInt64 {
+		return 0, ErrInvalidSize
+	}
+
+	return int64(math.Ceil(n * float64(unit))), nil
+}
+
+// SizeLargerThanFilter matches the files larger than size.
+func SizeLargerThanFilter(size int64, mode SizeMode) Filter {
+	if size == math.MaxInt64 {
+		// nothing is larger, the empty range matches no file
+		return sizeFilter(math.MaxInt64, math.MaxInt64-1, mode)
+	}
+
+	return sizeFilter(size+1, math.MaxInt64, mode)
+}
+
+// SizeSmallerThanFilter matches the files smaller than size.
+func SizeSmallerThanFilter(size int64, mode SizeMode) Filter {
+	return sizeFilter(0, max(size, 0)-1, mode)
+}
+
+// SizeBetweenFilter matches the files of min to max size, both inclusive.
+func SizeBetweenFilter(min, max int64, mode SizeMode) Filter {
+	return sizeFilter(min, max, mode)
+}
+
+// ParseSizeFilter parses the size filter expression:
+//
+//	+100M         larger than 100MiB
+//	-1k           smaller than 1KiB
+//	10KiB..2GiB   between 10KiB and 2GiB, both inclusive, either end may be omitted
+//	4KB           exactly 4000 bytes
+//
+// Sizes are parsed by ParseSize.
+func ParseSizeFilter(expr string, mode SizeMode) (Filter, error) {
+	if from, to, ok := strings.Cut(expr, ".."); ok {
+		var (
+			minSize int64
+			maxSize int64 = math.MaxInt64
+			err     error
+		)
+
+		if from == "" && to == "" {
+			return nil, ErrInvalidSize
+		}
+
+		if from != "" {
+			if minSize, err = ParseSize(from); err != nil {
+				return nil, err
+			}
+		}
+
+		if to != "" {
+			if maxSize, err = ParseSize(to); err != nil {
+				return nil, err
+			}
+		}
+
+		if minSize > maxSize {
+			return nil, ErrInvalidSize
+		}
+
+		return SizeBetweenFilter(minSize, maxSize, mode), nil
+	}
+
+	var constructor func(size int64, mode SizeMode) Filter
+	switch {
+	case strings.HasPrefix(expr, "+"):
+		constructor, expr = SizeLargerThanFilter, expr[1:]
+	case strings.HasPrefix(expr, "-"):
+		constructor, expr = SizeSmallerThanFilter, expr[1:]
+	}
+
+	size, err := ParseSize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if constructor == nil {
+		return SizeBetweenFilter(size, size, mode), nil
+	}
+
+	return constructor(size, mode), nil
+}
+
+func sizeFilter(minSize, maxSize int64, mode SizeMode) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		size, ok := fileSize(file.FileInfo, mode)
+		return ok && size >= minSize && size <= maxSize
+	}), nameSizeFilter)
+}
+
+func fileSize(info os.FileInfo, mode SizeMode) (int64, bool) {
+	if mode == SizeApparent {
+		return info.Size(), true
+	}
+
+	if stx, ok := StatxOf(info); ok {
+		return stx.Blocks() * 512, true
+	}
+
+	blocks, ok := allocatedBlocks(info)
+	return blocks * 512, ok
+}
@@ -0,0 +1,137 @@
+package scanner_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		size int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"1k", KiB},
+		{"1K", KiB},
+		{"1KB", KB},
+		{"1kB", KB},
+		{"10KiB", 10 * KiB},
+		{"100M", 100 * MiB},
+		{"2GiB", 2 * GiB},
+		{"3TB", 3 * TB},
+		{"1.5G", 3 * GiB / 2},
+		{"0.5", 1},
+	} {
+		t.Run("When "+tc.s+" is parsed", ScannerTest(func(t *testing.T) {
+			size, err := ParseSize(tc.s)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size).To(Equal(tc.size))
+		}))
+	}
+
+	t.Run("When size is invalid", ScannerTest(func(t *testing.T) {
+		for _, s := range []string{"", "k", "-1", "1PB", "1..2", "1 k", "99999999999T"} {
+			_, err := ParseSize(s)
+			Expect(err).To(Equal(ErrInvalidSize), s)
+		}
+	}))
+}
+
+func TestSizeFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"empty.txt": {},
+		"1000.txt":  {Data: []byte(strings.Repeat("x", 1000))},
+		"1024.txt":  {Data: []byte(strings.Repeat("x", 1024))},
+		"4096.txt":  {Data: []byte(strings.Repeat("x", 4096))},
+		"5000.txt":  {Data: []byte(strings.Repeat("x", 5000))},
+	}
+
+	scan := func(filter Filter) []string {
+		s := NewFilterScanner(MustScanner(NewBasicScanner(WithFS(fsys), WithDir("."))), filter)
+		return SortedPathNames(FileChanToPathNames(MustScan(s.Scan(context.TODO()))))
+	}
+
+	t.Run("When filters are constructed", ScannerTest(func(t *testing.T) {
+		Expect(scan(SizeLargerThanFilter(KiB, SizeApparent))).To(Equal([]string{"4096.txt", "5000.txt"}))
+		Expect(scan(SizeSmallerThanFilter(KiB, SizeApparent))).To(Equal([]string{"1000.txt", "empty.txt"}))
+		Expect(scan(SizeBetweenFilter(KiB, 4*KiB, SizeApparent))).To(Equal([]string{"1024.txt", "4096.txt"}))
+		Expect(scan(OrFilter(
+			SizeSmallerThanFilter(1, SizeApparent),
+			SizeLargerThanFilter(4*KiB, SizeApparent),
+		))).To(Equal([]string{"5000.txt", "empty.txt"}))
+		Expect(scan(SizeLargerThanFilter(math.MaxInt64, SizeApparent))).To(BeEmpty())
+		Expect(scan(SizeSmallerThanFilter(math.MinInt64, SizeApparent))).To(BeEmpty())
+	}))
+
+	for _, tc := range []struct {
+		expr      string
+		pathNames []string
+	}{
+		{"+1k", []string{"4096.txt", "5000.txt"}},
+		{"-1k", []string{"1000.txt", "empty.txt"}},
+		{"1KB..4KiB", []string{"1000.txt", "1024.txt", "4096.txt"}},
+		{"1KiB..", []string{"1024.txt", "4096.txt", "5000.txt"}},
+		{"..1000", []string{"1000.txt", "empty.txt"}},
+		{"4k", []string{"4096.txt"}},
+		{"0", []string{"empty.txt"}},
+	} {
+		t.Run("When "+tc.expr+" is parsed", ScannerTest(func(t *testing.T) {
+			filter, err := ParseSizeFilter(tc.expr, SizeApparent)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scan(filter)).To(Equal(tc.pathNames))
+		}))
+	}
+
+	t.Run("When expression is invalid", ScannerTest(func(t *testing.T) {
+		for _, expr := range []string{"", "+", "..", "4k..1k", "+-1k", "1k..2x"} {
+			_, err := ParseSizeFilter(expr, SizeApparent)
+			Expect(err).To(Equal(ErrInvalidSize), expr)
+		}
+	}))
+
+	t.Run("When allocated size is compared", ScannerTest(func(t *testing.T) {
+		dir := t.TempDir()
+
+		sparse, err := os.Create(filepath.Join(dir, "sparse.img"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sparse.Truncate(10 * MiB)).To(Succeed())
+		Expect(sparse.Close()).To(Succeed())
+
+		s := MustScanner(NewBasicScanner(WithDir(dir)))
+		apparent := FileChanToPathNames(MustScan(NewFilterScanner(s, MustParseSizeFilter("+1M", SizeApparent)).Scan(context.TODO())))
+		allocated := FileChanToPathNames(MustScan(NewFilterScanner(s, MustParseSizeFilter("-1M", SizeAllocated)).Scan(context.TODO())))
+
+		Expect(apparent).To(Equal([]string{sparse.Name()}))
+		Expect(allocated).To(Equal([]string{sparse.Name()}))
+	}))
+
+	t.Run("When builder is given the sizes", ScannerTest(func(t *testing.T) {
+		fileChan, err := NewBuilder().Flat().FS(fsys).In(".").Size("+1000").Size("-5000").MustBuild().Scan(context.TODO())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SortedPathNames(FileChanToPathNames(fileChan))).To(Equal([]string{"1024.txt", "4096.txt"}))
+
+		_, err = NewBuilder().Flat().In(".").AllocatedSize("+1x").Build()
+		Expect(err).To(Equal(ErrInvalidSize))
+	}))
+}
+
+func MustParseSizeFilter(expr string, mode SizeMode) Filter {
+	filter, err := ParseSizeFilter(expr, mode)
+	if err != nil {
+		panic(err)
+	}
+
+	return filter
+}
@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+var ErrStatxNotSupported = errors.New("statx is not supported")
+
+const (
+	nameAttributesFilter = "AttributesFilter"
+	nameMountIDFilter    = "MountIDFilter"
+)
+
+// StatxFileInfo is the FileInfo of the file stat-ed with statx, see WithStatx.
+type StatxFileInfo interface {
+	os.FileInfo
+	// BirthTime is the creation time of the file, zero when the filesystem does not record it
+	BirthTime() time.Time
+	// MountID is the ID of the mount the file is placed on, 0 when the kernel does not report it
+	MountID() uint64
+	// Attributes are the STATX_ATTR flags set on the file
+	Attributes() StatxAttributes
+	// AttributesMask are the STATX_ATTR flags the filesystem supports at all
+	AttributesMask() StatxAttributes
+	// Blocks is the number of the 512-byte blocks allocated for the file
+	Blocks() int64
+}
+
+// StatxAttributes are the STATX_ATTR flags of the file.
+type StatxAttributes uint64
+
+const (
+	AttrCompressed StatxAttributes = 0x00000004
+	AttrImmutable  StatxAttributes = 0x00000010
+	AttrAppend     StatxAttributes = 0x00000020
+	AttrNodump     StatxAttributes = 0x00000040
+	AttrEncrypted  StatxAttributes = 0x00000800
+	AttrAutomount  StatxAttributes = 0x00001000
+	AttrMountRoot  StatxAttributes = 0x00002000
+	AttrVerity     StatxAttributes = 0x00100000
+	AttrDax        StatxAttributes = 0x00200000
+)
+
+// Has tells whether all the attrs are set.
+func (a StatxAttributes) Has(attrs StatxAttributes) bool {
+	return a&attrs == attrs
+}
+
+// StatxOf returns the statx of the file, false unless it has been scanned WithStatx. In the lazy-stat
+// mode the file is stat-ed then.
+func StatxOf(info os.FileInfo) (StatxFileInfo, bool) {
+	if i, ok := info.(interface{ Info() (os.FileInfo, error) }); ok {
+		var err error
+		if info, err = i.Info(); err != nil {
+			return nil, false
+		}
+	}
+
+	stx, ok := info.(StatxFileInfo)
+	return stx, ok
+}
+
+// AttributesFilter matches the files having all the attrs set, it needs WithStatx.
+func AttributesFilter(attrs StatxAttributes) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		stx, ok := StatxOf(file.FileInfo)
+		return ok && stx.Attributes().Has(attrs)
+	}), nameAttributesFilter)
+}
+
+// MountIDFilter matches the files placed on the mount, it needs WithStatx.
+func MountIDFilter(mountID uint64) Filter {
+	return MakeNamedFilter(FilterFn(func(file FileItem) bool {
+		if file.FileInfo == nil {
+			return false
+		}
+
+		stx, ok := StatxOf(file.FileInfo)
+		return ok && stx.MountID() == mountID
+	}), nameMountIDFilter)
+}
@@ -0,0 +1,208 @@
+//go:build linux
+
+package scanner
+
+import (
+	"os"
+	"path"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const statxSupported = true
+
+// statxMask is what the scanner asks statx for, the kernel leaves out what it can't tell.
+const statxMask = unix.STATX_BASIC_STATS | unix.STATX_BTIME | unix.STATX_MNT_ID
+
+func statxTime(t unix.StatxTimestamp) time.Time {
+	return time.Unix(t.Sec, int64(t.Nsec))
+}
+
+func statxTimespec(t unix.StatxTimestamp) syscall.Timespec {
+	return syscall.NsecToTimespec(statxTime(t).UnixNano())
+}
+
+// statx stats the file without following the symlink. The name is relative to the directory,
+// or to the working directory when there is none.
+func statx(dir *os.File, name string) (os.FileInfo, error) {
+	if dir == nil {
+		return statxCall(unix.AT_FDCWD, name, path.Base(name), unix.AT_SYMLINK_NOFOLLOW)
+	}
+
+	var (
+		info os.FileInfo
+		err  error
+	)
+
+	if controlErr := controlFd(dir, func(fd int) {
+		info, err = statxCall(fd, name, path.Join(dir.Name(), name), unix.AT_SYMLINK_NOFOLLOW)
+	}); controlErr != nil {
+		return nil, controlErr
+	}
+
+	return info, err
+}
+
+// statxFile stats the opened file itself.
+func statxFile(f *os.File) (os.FileInfo, error) {
+	var (
+		info os.FileInfo
+		err  error
+	)
+
+	if controlErr := controlFd(f, func(fd int) {
+		info, err = statxCall(fd, "", f.Name(), unix.AT_EMPTY_PATH)
+	}); controlErr != nil {
+		return nil, controlErr
+	}
+
+	return info, err
+}
+
+// statxFollow stats the file by its path, following the symlink the way os.Stat does.
+func statxFollow(name string) (os.FileInfo, error) {
+	return statxCall(unix.AT_FDCWD, name, name, 0)
+}
+
+func controlFd(f *os.File, fn func(fd int)) error {
+	rawConn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	return rawConn.Control(func(fd uintptr) {
+		fn(int(fd))
+	})
+}
+
+func statxCall(fd int, name, pathName string, flags int) (os.FileInfo, error) {
+	info := &statxFileInfo{name: path.Base(pathName)}
+	err := ignoringEINTR(func() error {
+		return unix.Statx(fd, name, flags, statxMask, &info.stx)
+	})
+
+	switch err {
+	case nil:
+		return info, nil
+	case unix.ENOSYS:
+		return nil, ErrStatxNotSupported
+	}
+
+	return nil, &os.PathError{Op: "statx", Path: pathName, Err: err}
+}
+
+// statxFileInfo is os.FileInfo out of statx, Sys is the *syscall.Stat_t the same way as os.Lstat.
+type statxFileInfo struct {
+	name string
+	stx  unix.Statx_t
+}
+
+func (i *statxFileInfo) Name() string {
+	return i.name
+}
+
+func (i *statxFileInfo) Size() int64 {
+	return int64(i.stx.Size)
+}
+
+func (i *statxFileInfo) Mode() os.FileMode {
+	return unixFileMode(uint32(i.stx.Mode))
+}
+
+func (i *statxFileInfo) ModTime() time.Time {
+	return statxTime(i.stx.Mtime)
+}
+
+func (i *statxFileInfo) IsDir() bool {
+	return i.Mode().IsDir()
+}
+
+func (i *statxFileInfo) Sys() interface{} {
+	st := &syscall.Stat_t{
+		Ino:  i.stx.Ino,
+		Mode: uint32(i.stx.Mode),
+		Uid:  i.stx.Uid,
+		Gid:  i.stx.Gid,
+		Size: int64(i.stx.Size),
+		Atim: statxTimespec(i.stx.Atime),
+		Mtim: statxTimespec(i.stx.Mtime),
+		Ctim: statxTimespec(i.stx.Ctime),
+	}
+
+	// the types of these differ between the architectures
+	setInteger(&st.Dev, unix.Mkdev(i.stx.Dev_major, i.stx.Dev_minor))
+	setInteger(&st.Rdev, unix.Mkdev(i.stx.Rdev_major, i.stx.Rdev_minor))
+	setInteger(&st.Nlink, uint64(i.stx.Nlink))
+	setInteger(&st.Blksize, uint64(i.stx.Blksize))
+	setInteger(&st.Blocks, i.stx.Blocks)
+
+	return st
+}
+
+func (i *statxFileInfo) BirthTime() time.Time {
+	if i.stx.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}
+	}
+
+	return statxTime(i.stx.Btime)
+}
+
+func (i *statxFileInfo) MountID() uint64 {
+	if i.stx.Mask&unix.STATX_MNT_ID == 0 {
+		return 0
+	}
+
+	return i.stx.Mnt_id
+}
+
+func (i *statxFileInfo) Attributes() StatxAttributes {
+	return StatxAttributes(i.stx.Attributes)
+}
+
+func (i *statxFileInfo) AttributesMask() StatxAttributes {
+	return StatxAttributes(i.stx.Attributes_mask)
+}
+
+func (i *statxFileInfo) Blocks() int64 {
+	return int64(i.stx.Blocks)
+}
+
+func setInteger[T ~int32 | ~int64 | ~uint32 | ~uint64](dst *T, v uint64) {
+	*dst = T(v)
+}
+
+// unixFileMode converts st_mode the same way the os package does.
+func unixFileMode(stMode uint32) os.FileMode {
+	mode := os.FileMode(stMode & 0777)
+
+	switch stMode & unix.S_IFMT {
+	case unix.S_IFBLK:
+		mode |= os.ModeDevice
+	case unix.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case unix.S_IFDIR:
+		mode |= os.ModeDir
+	case unix.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case unix.S_IFLNK:
+		mode |= os.ModeSymlink
+	case unix.S_IFSOCK:
+		mode |= os.ModeSocket
+	}
+
+	if stMode&unix.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+
+	if stMode&unix.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+
+	if stMode&unix.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+
+	return mode
+}
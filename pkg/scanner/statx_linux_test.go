@@ -0,0 +1,108 @@
+//go:build linux
+
+package scanner_test
+
+import (
+	"context"
+	"path"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestWithStatx(t *testing.T) {
+	dir := NewDirectoryPath("directory-to-statx")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("level-0-file-1.jpg"),
+		NewWorkspaceDir("level-0-directory-1",
+			NewWorkspaceFile("level-1-file-1.1.jpg"),
+		),
+		NewWorkspaceSymlink("level-0-symlink-1", "level-0-directory-1"),
+	)).Purge()
+	MustWriteFile(path.Join(dir, "level-0-file-1.jpg"), "content")
+
+	t.Run("When scanning with statx", ScannerTest(func(t *testing.T) {
+		eager := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))).Scan(context.TODO())))
+
+		for _, options := range [][]BasicScannerOptionFn{
+			{WithStatx()},
+			{WithStatx(), WithLazyStat()},
+			{WithStatx(), WithGetdents()},
+			{WithStatx(), WithOpenat()},
+		} {
+			files := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithSameFilesystem(), WithScannerOptions(append(options, WithOrder(OrderLexical))...))).Scan(context.TODO())))
+			Expect(files).To(HaveLen(len(eager)))
+
+			for i := range eager {
+				stx, ok := StatxOf(files[i].FileInfo)
+				Expect(ok).To(BeTrue())
+
+				Expect(files[i].FileInfo.PathName()).To(Equal(eager[i].FileInfo.PathName()))
+				Expect(stx.Name()).To(Equal(eager[i].FileInfo.Name()))
+				Expect(stx.Mode()).To(Equal(eager[i].FileInfo.Mode()))
+				Expect(stx.Size()).To(Equal(eager[i].FileInfo.Size()))
+				Expect(stx.ModTime()).To(Equal(eager[i].FileInfo.ModTime()))
+				Expect(stx.BirthTime().After(time.Now())).To(BeFalse())
+
+				st, expected := stx.Sys().(*syscall.Stat_t), eager[i].FileInfo.Sys().(*syscall.Stat_t)
+				Expect(st.Dev).To(Equal(expected.Dev))
+				Expect(st.Ino).To(Equal(expected.Ino))
+				Expect(st.Nlink).To(Equal(expected.Nlink))
+				Expect(st.Mtim).To(Equal(expected.Mtim))
+				Expect(stx.Blocks()).To(Equal(int64(expected.Blocks)))
+			}
+		}
+	}))
+
+	t.Run("When filtering by statx", ScannerTest(func(t *testing.T) {
+		files := FileChanToSlice(MustScan(MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithStatx()))).Scan(context.TODO())))
+		stx, ok := StatxOf(files[0].FileInfo)
+		Expect(ok).To(BeTrue())
+
+		mounted := FileChanToSlice(MustScan(NewFilterScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithStatx()))),
+			AndFilter(MountIDFilter(stx.MountID()), AttributesFilter(0)),
+		).Scan(context.TODO())))
+		Expect(mounted).To(HaveLen(len(files)))
+
+		immutable := FileChanToSlice(MustScan(NewFilterScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithStatx()))),
+			AttributesFilter(AttrImmutable),
+		).Scan(context.TODO())))
+		Expect(immutable).To(BeEmpty())
+
+		notStatx := FileChanToSlice(MustScan(NewFilterScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories(dir))),
+			AttributesFilter(0),
+		).Scan(context.TODO())))
+		Expect(notStatx).To(BeEmpty())
+	}))
+
+	t.Run("When directory events are emitted", ScannerTest(func(t *testing.T) {
+		files := FileChanToSlice(MustScan(MustScanner(NewBasicScanner(WithDir(dir), WithStatx(), WithDirEvents())).Scan(context.TODO())))
+		Expect(files[0].Kind).To(Equal(KindDirStart))
+
+		stx, ok := StatxOf(files[0].FileInfo)
+		Expect(ok).To(BeTrue())
+		Expect(stx.IsDir()).To(BeTrue())
+		Expect(stx.Name()).To(Equal(path.Base(dir)))
+	}))
+
+	t.Run("When statx can't be used", ScannerTest(func(t *testing.T) {
+		_, err := NewBasicScanner(WithFS(fstest.MapFS{}), WithStatx())
+		Expect(err).To(Equal(ErrStatxNotSupported))
+	}))
+
+	t.Run("When statx is built", ScannerTest(func(t *testing.T) {
+		scanner, err := NewBuilder().Recursive().In("/tmp").Statx().Build()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scanner).To(BeScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories("/tmp"), WithScannerOptions(WithStatx()))),
+		))
+	}))
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package scanner
+
+import "os"
+
+const statxSupported = false
+
+func statx(dir *os.File, name string) (os.FileInfo, error) {
+	return nil, ErrStatxNotSupported
+}
+
+func statxFile(f *os.File) (os.FileInfo, error) {
+	return nil, ErrStatxNotSupported
+}
+
+func statxFollow(name string) (os.FileInfo, error) {
+	return nil, ErrStatxNotSupported
+}
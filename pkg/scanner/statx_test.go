@@ -0,0 +1,18 @@
+package scanner_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+func TestAttributes(t *testing.T) {
+	t.Run("When checking the attributes", ScannerTest(func(t *testing.T) {
+		attrs := AttrCompressed | AttrNodump
+		Expect(attrs.Has(AttrCompressed)).To(BeTrue())
+		Expect(attrs.Has(AttrCompressed | AttrNodump)).To(BeTrue())
+		Expect(attrs.Has(AttrCompressed | AttrImmutable)).To(BeFalse())
+		Expect(attrs.Has(0)).To(BeTrue())
+	}))
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// WalkFn is called for every item of the scanner, the directory events included. Returning
+// fs.SkipDir for a directory skips its content, for any other item it skips the rest of the
+// directory the item has been found in. Returning fs.SkipAll stops the walk, so does any other
+// error, which is returned by Walk then.
+type WalkFn func(item FileItem) error
+
+// Walk calls fn for every item of the scanner, never concurrently.
+func Walk(ctx context.Context, s Scanner, fn WalkFn) error {
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := walkState{fn: fn, cancel: cancel}
+	walk(walkCtx, s, w.emit)
+
+	switch {
+	case w.err != nil:
+		return w.err
+	case w.stopped:
+		return nil
+	default:
+		return ctx.Err()
+	}
+}
+
+type walkAction int8
+
+const (
+	walkContinue walkAction = iota
+	walkSkipDir
+	walkStop
+)
+
+// emitFn passes the item to the consumer and returns its reaction.
+type emitFn func(item FileItem) walkAction
+
+func yieldEmit(yield func(FileItem) bool) emitFn {
+	return func(item FileItem) walkAction {
+		if !yield(item) {
+			return walkStop
+		}
+
+		return walkContinue
+	}
+}
+
+// sendEmit sends the items to the channel, see send.
+func sendEmit(ctx context.Context, fileChan FileItemChan) emitFn {
+	return func(item FileItem) walkAction {
+		if !send(ctx, fileChan, item) {
+			return walkStop
+		}
+
+		return walkContinue
+	}
+}
+
+// walker is implemented by the scanners able to skip the directories the consumer is not
+// interested in. It returns once the scanning is done, or the context is done.
+type walker interface {
+	walk(ctx context.Context, emit emitFn)
+}
+
+func walk(ctx context.Context, s Scanner, emit emitFn) {
+	if w, ok := s.(walker); ok {
+		w.walk(ctx, emit)
+		return
+	}
+
+	walkItems(ctx, s, emit)
+}
+
+// walkItems walks any scanner. Skipped directories are read anyway, but their items are dropped.
+func walkItems(ctx context.Context, s Scanner, emit emitFn) {
+	var (
+		// skipped holds the path prefixes, skippedDirs the directories which events are dropped too
+		skipped     []string
+		skippedDirs = make(map[string]bool)
+	)
+
+	isSkipped := func(item FileItem) bool {
+		if item.FileInfo == nil {
+			return false
+		}
+
+		pathName := item.FileInfo.PathName()
+		if item.Kind != KindEntry && skippedDirs[pathName] {
+			return true
+		}
+
+		for _, prefix := range skipped {
+			if strings.HasPrefix(pathName, prefix) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for item := range items(ctx, s) {
+		if isSkipped(item) {
+			continue
+		}
+
+		switch emit(item) {
+		case walkStop:
+			return
+		case walkSkipDir:
+			if item.FileInfo == nil || item.Kind == KindDirEnd {
+				continue
+			}
+
+			pathName := item.FileInfo.PathName()
+			switch {
+			case item.Kind == KindDirStart:
+				skipped = append(skipped, pathName+"/")
+			case item.FileInfo.IsDir():
+				skipped = append(skipped, pathName+"/")
+				skippedDirs[pathName] = true
+			default:
+				skipped = append(skipped, path.Dir(pathName)+"/")
+			}
+		}
+	}
+}
+
+// walkState serializes the calls of fn and remembers why the walk has been stopped.
+type walkState struct {
+	mu      sync.Mutex
+	fn      WalkFn
+	cancel  context.CancelFunc
+	stopped bool
+	err     error
+}
+
+func (w *walkState) emit(item FileItem) walkAction {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return walkStop
+	}
+
+	switch err := w.fn(item); {
+	case err == nil:
+		return walkContinue
+	case errors.Is(err, fs.SkipDir):
+		return walkSkipDir
+	case !errors.Is(err, fs.SkipAll):
+		w.err = err
+	}
+
+	w.stopped = true
+	w.cancel()
+
+	return walkStop
+}
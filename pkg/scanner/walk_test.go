@@ -0,0 +1,235 @@
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	. "github.com/wojteninho/scanner/pkg/scanner"
+)
+
+// ChannelScanner hides everything but the Scan method of the scanner.
+type ChannelScanner struct {
+	Scanner
+}
+
+func TestWalk(t *testing.T) {
+	dir := NewDirectoryPath("nested-directory-to-walk")
+	defer MustNewWorkspace(dir, WithItems(
+		NewWorkspaceFile("a-file.jpg"),
+		NewWorkspaceDir("b-directory",
+			NewWorkspaceFile("a-file.jpg"),
+			NewWorkspaceDir("b-skipped-directory",
+				NewWorkspaceFile("a-file.jpg"),
+				NewWorkspaceDir("b-directory",
+					NewWorkspaceFile("a-file.jpg"),
+				),
+			),
+			NewWorkspaceFile("c-file.jpg"),
+		),
+		NewWorkspaceDir("c-directory",
+			NewWorkspaceFile("a-file.jpg"),
+			NewWorkspaceFile("b-skipping-file.jpg"),
+			NewWorkspaceDir("c-directory",
+				NewWorkspaceFile("a-file.jpg"),
+			),
+			NewWorkspaceFile("d-file.jpg"),
+		),
+	)).Purge()
+
+	skippingFn := func(relPaths *[]string) WalkFn {
+		return func(item FileItem) error {
+			Expect(item.Err).ToNot(HaveOccurred())
+			if item.Kind == KindDirStart {
+				*relPaths = append(*relPaths, "start:"+item.FileInfo.RelPath())
+				return nil
+			}
+
+			if item.Kind == KindDirEnd {
+				return nil
+			}
+
+			*relPaths = append(*relPaths, item.FileInfo.RelPath())
+			switch path.Base(item.FileInfo.Name()) {
+			case "b-skipped-directory", "b-skipping-file.jpg":
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+	}
+
+	expected := []string{
+		"start:.",
+		"a-file.jpg",
+		"b-directory",
+		"start:b-directory",
+		"b-directory/a-file.jpg",
+		"b-directory/b-skipped-directory",
+		"b-directory/c-file.jpg",
+		"c-directory",
+		"start:c-directory",
+		"c-directory/a-file.jpg",
+		"c-directory/b-skipping-file.jpg",
+	}
+
+	// rest of the directory is skipped after the file, so only the ordered listings are deterministic
+	unorderedScanners := map[string]func() Scanner{
+		"parallel RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4), WithScannerOptions(WithDirEvents())))
+		},
+		"single worker RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(1), WithScannerOptions(WithDirEvents())))
+		},
+	}
+
+	for name, newScanner := range unorderedScanners {
+		t.Run(fmt.Sprintf("When skipping directories of %s", name), ScannerTest(func(t *testing.T) {
+			var relPaths []string
+
+			Expect(Walk(context.TODO(), newScanner(), skippingFn(&relPaths))).To(Succeed())
+			Expect(relPaths).To(ContainElement("b-directory/b-skipped-directory"))
+			Expect(relPaths).To(ContainElement("c-directory/b-skipping-file.jpg"))
+			for _, relPath := range relPaths {
+				Expect(relPath).ToNot(ContainSubstring("b-skipped-directory/"))
+				Expect(relPath).ToNot(Equal("start:b-directory/b-skipped-directory"))
+			}
+		}))
+	}
+
+	scanners := map[string]func() Scanner{
+		"breadth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithStrategy(StrategyBreadthFirst),
+				WithScannerOptions(WithDirEvents(), WithOrder(OrderLexical)),
+			))
+		},
+		"depth-first RecursiveScanner": func() Scanner {
+			return MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithStrategy(StrategyDepthFirst),
+				WithScannerOptions(WithDirEvents(), WithOrder(OrderLexical)),
+			))
+		},
+		"DebugScanner": func() Scanner {
+			return NewDebugScanner(MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithScannerOptions(WithDirEvents(), WithOrder(OrderLexical)),
+			)), func(FileItem) {})
+		},
+		"not walkable scanner": func() Scanner {
+			return ChannelScanner{MustScanner(NewRecursiveScanner(
+				WithDirectories(dir),
+				WithScannerOptions(WithDirEvents(), WithOrder(OrderLexical)),
+			))}
+		},
+	}
+
+	for name, newScanner := range scanners {
+		t.Run(fmt.Sprintf("When skipping directories of %s", name), ScannerTest(func(t *testing.T) {
+			var relPaths []string
+
+			Expect(Walk(context.TODO(), newScanner(), skippingFn(&relPaths))).To(Succeed())
+			Expect(relPaths).To(ConsistOf(expected))
+		}))
+	}
+
+	t.Run("When skipping directories of FilterScanner", ScannerTest(func(t *testing.T) {
+		var relPaths []string
+
+		s := NewFilterScanner(
+			MustScanner(NewRecursiveScanner(WithDirectories(dir), WithScannerOptions(WithOrder(OrderLexical)))),
+			FilterFn(func(item FileItem) bool { return !strings.HasPrefix(item.FileInfo.Name(), "a-") }),
+		)
+
+		Expect(Walk(context.TODO(), s, skippingFn(&relPaths))).To(Succeed())
+		Expect(relPaths).To(ConsistOf(
+			"b-directory",
+			"b-directory/b-skipped-directory",
+			"b-directory/c-file.jpg",
+			"c-directory",
+			"c-directory/b-skipping-file.jpg",
+		))
+	}))
+
+	t.Run("When walking BasicScanner", ScannerTest(func(t *testing.T) {
+		var relPaths []string
+
+		s := MustScanner(NewBasicScanner(WithDir(dir+"/c-directory"), WithOrder(OrderLexical)))
+		Expect(Walk(context.TODO(), s, skippingFn(&relPaths))).To(Succeed())
+		Expect(relPaths).To(Equal([]string{"a-file.jpg", "b-skipping-file.jpg"}))
+	}))
+
+	t.Run("When walking MultiScanner", ScannerTest(func(t *testing.T) {
+		var relPaths []string
+
+		s := NewMultiScanner(
+			MustScanner(NewBasicScanner(WithDir(dir+"/b-directory"))),
+			MustScanner(NewRecursiveScanner(WithDirectories(dir+"/c-directory"), WithScannerOptions(WithOrder(OrderLexical)))),
+		)
+		Expect(Walk(context.TODO(), s, skippingFn(&relPaths))).To(Succeed())
+		Expect(relPaths).To(ConsistOf(
+			"a-file.jpg",
+			"b-skipped-directory",
+			"c-file.jpg",
+			"a-file.jpg",
+			"b-skipping-file.jpg",
+		))
+	}))
+
+	t.Run("When all is skipped", ScannerTest(func(t *testing.T) {
+		var calls int
+
+		err := Walk(context.TODO(), MustScanner(NewRecursiveScanner(WithDirectories(dir))), func(item FileItem) error {
+			calls++
+			return fs.SkipAll
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	}))
+
+	t.Run("When walk function fails", ScannerTest(func(t *testing.T) {
+		walkErr := errors.New("dummy error")
+
+		err := Walk(context.TODO(), MustScanner(NewRecursiveScanner(WithDirectories(dir))), func(item FileItem) error {
+			return walkErr
+		})
+
+		Expect(err).To(Equal(walkErr))
+	}))
+
+	t.Run("When context is cancelled", ScannerTest(func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := Walk(ctx, MustScanner(NewRecursiveScanner(WithDirectories(dir))), func(item FileItem) error {
+			cancel()
+			return nil
+		})
+
+		Expect(err).To(Equal(context.Canceled))
+	}))
+
+	t.Run("When walking with many workers", ScannerTest(func(t *testing.T) {
+		var inFlight, calls int32
+
+		err := Walk(context.TODO(), MustScanner(NewRecursiveScanner(WithDirectories(dir), WithWorkers(4))), func(item FileItem) error {
+			Expect(atomic.AddInt32(&inFlight, 1)).To(Equal(int32(1)))
+			defer atomic.AddInt32(&inFlight, -1)
+
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal(int32(14)))
+	}))
+}